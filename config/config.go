@@ -3,17 +3,69 @@ package config
 import (
 	"fmt"
 	"log"
+	"math/big"
 	"os"
+	"strconv"
+	"text/template"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"gopkg.in/yaml.v2"
+
+	"github.com/b-harvest/ethrpc-checker/utils"
 )
 
 type Config struct {
 	RpcEndpoint string `yaml:"rpc_endpoint"`
+	// WsEndpoint is the optional websocket endpoint, connected only if UseSubscriptionForTx is set
+	WsEndpoint  string `yaml:"ws_endpoint"`
 	RichPrivKey string `yaml:"rich_privkey"`
 	// Timeout is the timeout for the RPC (e.g. 5s, 1m)
 	Timeout string `yaml:"timeout"`
+	// StorageAtSlotIndex is the slot index of the mapping queried by eth_getStorageAt (default 4, balanceOf of ERC20)
+	StorageAtSlotIndex uint64 `yaml:"storage_at_slot_index"`
+	// StorageAtAddress is the contract address queried by eth_getStorageAt (default: deployed ERC20 contract)
+	StorageAtAddress string `yaml:"storage_at_address"`
+	// ConnectTimeoutMs is the timeout for connecting to the RPC endpoint in milliseconds (default 10000)
+	ConnectTimeoutMs int `yaml:"connect_timeout_ms"`
+	// UseSubscriptionForTx waits for transactions by subscribing to newHeads via WsEndpoint instead of polling
+	UseSubscriptionForTx bool `yaml:"use_subscription_for_tx"`
+	// RetryCount is the number of attempts of each RPC test on network errors and rate limiting (default 1 = no retry)
+	RetryCount int `yaml:"retry_count"`
+	// MethodRetries is the number of retries of each RPC test (default 0). It is used only if RetryCount is 1,
+	// see MaxAttempts.
+	MethodRetries int `yaml:"method_retries"`
+	// RetryBaseDelayMs is the delay before the first retry in milliseconds, doubled on each retry (default 500)
+	RetryBaseDelayMs int `yaml:"retry_base_delay_ms"`
+	// RetryMaxDelayMs caps the delay between retries in milliseconds (default 10000)
+	RetryMaxDelayMs int `yaml:"retry_max_delay_ms"`
+	// TestGroups maps group names to the RPC method names run by -group flag
+	TestGroups map[string][]string `yaml:"test_groups"`
+	// MaxTestDuration is the deadline of the whole test run (e.g. 10m). Empty means no deadline.
+	MaxTestDuration string `yaml:"max_test_duration"`
+	// MinBalance is the minimum balance of the rich account in ETH (e.g. 0.01)
+	MinBalance string `yaml:"min_balance"`
+	// MinBalanceWei is the minimum balance of the rich account in wei. It takes precedence over MinBalance if set.
+	MinBalanceWei string `yaml:"min_balance_wei"`
+	// MinBlockGasLimit is the minimum gas limit of a block, lower is an error (default 5000)
+	MinBlockGasLimit uint64 `yaml:"min_block_gas_limit"`
+	// MaxBlockGasLimit is the maximum reasonable gas limit of a block, higher is a warning (default 30000000000)
+	MaxBlockGasLimit uint64 `yaml:"max_block_gas_limit"`
+	// MergeBlock is the first PoS block number of the chain. 0 means pre-Merge and skips PoS checks.
+	MergeBlock uint64 `yaml:"merge_block"`
+	// ShanghaiBlock is the first block number after the Shanghai upgrade. 0 means pre-Shanghai.
+	ShanghaiBlock uint64 `yaml:"shanghai_block"`
+	// CancunBlock is the first block number after the Cancun upgrade (EIP-4844). 0 means pre-Cancun.
+	CancunBlock uint64 `yaml:"cancun_block"`
+	// DencunBlock is the first block number after the Dencun upgrade (EIP-4788). 0 means pre-Dencun.
+	DencunBlock uint64 `yaml:"dencun_block"`
+	// CustomValidations maps RPC method names to text/template expressions evaluated with the result value,
+	// a warning is added to the result if the expression does not evaluate to true
+	CustomValidations map[string]string `yaml:"custom_validations"`
+	// ExpectedChainId is the chain ID the endpoint must return, a mismatch is an error. 0 means no check.
+	ExpectedChainId int64 `yaml:"expected_chain_id"`
+	// MaxLatencyMs downgrades Ok results slower than it to Warning. 0 means no limit.
+	MaxLatencyMs int `yaml:"max_latency_ms"`
 }
 
 func (c *Config) Validate() error {
@@ -26,22 +78,143 @@ func (c *Config) Validate() error {
 	if _, err := time.ParseDuration(c.Timeout); err != nil {
 		return fmt.Errorf("invalid timeout: %v", err)
 	}
+	if c.MaxTestDuration != "" {
+		if _, err := time.ParseDuration(c.MaxTestDuration); err != nil {
+			return fmt.Errorf("invalid max_test_duration: %v", err)
+		}
+	}
+	if minBalance, err := utils.ParseEther(c.MinBalance); err != nil {
+		return fmt.Errorf("invalid min_balance: %v", err)
+	} else if minBalance.Sign() < 0 {
+		return fmt.Errorf("min_balance must not be negative")
+	}
+	if c.MinBalanceWei != "" {
+		if minBalanceWei, ok := new(big.Int).SetString(c.MinBalanceWei, 10); !ok {
+			return fmt.Errorf("invalid min_balance_wei: %s", c.MinBalanceWei)
+		} else if minBalanceWei.Sign() < 0 {
+			return fmt.Errorf("min_balance_wei must not be negative")
+		}
+	}
+	if c.ConnectTimeoutMs <= 0 {
+		return fmt.Errorf("connect_timeout_ms must be positive")
+	}
+	if c.RetryCount < 1 {
+		return fmt.Errorf("retry_count must be at least 1")
+	}
+	if c.MethodRetries < 0 {
+		return fmt.Errorf("method_retries must not be negative")
+	}
+	if c.RetryCount > 1 && c.MethodRetries > 0 && c.RetryCount != c.MethodRetries+1 {
+		return fmt.Errorf("retry_count %d conflicts with method_retries %d, set only one of them", c.RetryCount, c.MethodRetries)
+	}
+	if c.RetryBaseDelayMs < 0 {
+		return fmt.Errorf("retry_base_delay_ms must not be negative")
+	}
+	if c.RetryMaxDelayMs < c.RetryBaseDelayMs {
+		return fmt.Errorf("retry_max_delay_ms must not be less than retry_base_delay_ms")
+	}
+	if c.ExpectedChainId < 0 {
+		return fmt.Errorf("expected_chain_id must not be negative")
+	}
+	if c.MaxLatencyMs < 0 {
+		return fmt.Errorf("max_latency_ms must not be negative")
+	}
+	if c.MinBlockGasLimit > c.MaxBlockGasLimit {
+		return fmt.Errorf("min_block_gas_limit must not be greater than max_block_gas_limit")
+	}
+	// 0 means the upgrade is not activated, otherwise the upgrades are activated in order
+	if c.ShanghaiBlock != 0 && c.ShanghaiBlock < c.MergeBlock {
+		return fmt.Errorf("shanghai_block must not be less than merge_block")
+	}
+	if c.CancunBlock != 0 && (c.CancunBlock < c.ShanghaiBlock || c.CancunBlock < c.MergeBlock) {
+		return fmt.Errorf("cancun_block must not be less than shanghai_block and merge_block")
+	}
+	if c.DencunBlock != 0 && (c.DencunBlock < c.ShanghaiBlock || c.DencunBlock < c.MergeBlock) {
+		return fmt.Errorf("dencun_block must not be less than shanghai_block and merge_block")
+	}
+	for method, expr := range c.CustomValidations {
+		if _, err := template.New(method).Parse(expr); err != nil {
+			return fmt.Errorf("invalid custom_validations of %s: %v", method, err)
+		}
+	}
+	if c.StorageAtAddress != "" && !common.IsHexAddress(c.StorageAtAddress) {
+		return fmt.Errorf("invalid storage_at_address: %s", c.StorageAtAddress)
+	}
 	return nil
 }
 
+// MinBalanceInWei returns the minimum balance of the rich account in wei, checked when connecting and by
+// eth_getBalance. min_balance_wei takes precedence over min_balance if set.
+func (c *Config) MinBalanceInWei() *big.Int {
+	// both are validated when the config is loaded
+	if c.MinBalanceWei != "" {
+		minBalance, _ := new(big.Int).SetString(c.MinBalanceWei, 10)
+		return minBalance
+	}
+	minBalance, _ := utils.ParseEther(c.MinBalance)
+	return minBalance
+}
+
+// MaxAttempts returns the number of attempts of each RPC test. retry_count takes precedence,
+// and method_retries is used only if retry_count is left at the default 1.
+func (c *Config) MaxAttempts() int {
+	if c.RetryCount <= 1 && c.MethodRetries > 0 {
+		return c.MethodRetries + 1
+	}
+	return c.RetryCount
+}
+
 func MustLoadConfig(filename string) *Config {
-	var config Config
-	file, err := os.ReadFile(filename)
+	config, err := LoadConfigWithEnv(filename)
 	if err != nil {
-		log.Fatalf("Failed to read config file: %v", err)
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	return config
+}
+
+// LoadConfigWithEnv loads the config file and overrides its fields with the environment variables
+// RPC_ENDPOINT, RICH_PRIVKEY, TIMEOUT, CHAIN_ID and MIN_BALANCE_WEI if set, so secrets don't have
+// to be written to disk. The config is validated after the overrides.
+func LoadConfigWithEnv(filename string) (*Config, error) {
+	config := Config{
+		StorageAtSlotIndex: 4,
+		ConnectTimeoutMs:   10000,
+		RetryCount:         1,
+		RetryBaseDelayMs:   500,
+		RetryMaxDelayMs:    10000,
+		TestGroups:         DefaultTestGroups(),
+		MinBalance:         "0.01",
+		MinBlockGasLimit:   5000,
+		MaxBlockGasLimit:   30000000000,
 	}
-	err = yaml.Unmarshal(file, &config)
+	file, err := os.ReadFile(filename)
 	if err != nil {
-		log.Fatalf("Failed to parse config file: %v", err)
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	if err = yaml.Unmarshal(file, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	if v, ok := os.LookupEnv("RPC_ENDPOINT"); ok {
+		config.RpcEndpoint = v
+	}
+	if v, ok := os.LookupEnv("RICH_PRIVKEY"); ok {
+		config.RichPrivKey = v
+	}
+	if v, ok := os.LookupEnv("TIMEOUT"); ok {
+		config.Timeout = v
+	}
+	if v, ok := os.LookupEnv("CHAIN_ID"); ok {
+		if config.ExpectedChainId, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid CHAIN_ID: %v", err)
+		}
+	}
+	if v, ok := os.LookupEnv("MIN_BALANCE_WEI"); ok {
+		config.MinBalanceWei = v
 	}
 
 	if err = config.Validate(); err != nil {
-		log.Fatalf("Invalid config: %v", err)
+		return nil, fmt.Errorf("invalid config: %w", err)
 	}
-	return &config
+	return &config, nil
 }
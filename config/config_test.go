@@ -0,0 +1,239 @@
+package config
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// validConfig returns a config passing Validate with the defaults of LoadConfigWithEnv
+func validConfig() *Config {
+	return &Config{
+		RpcEndpoint:      "http://localhost:8545",
+		RichPrivKey:      "b9d15599650f41dc705d1edf676830117d14bf41f7a06dac5d13228507cff77f",
+		Timeout:          "10s",
+		ConnectTimeoutMs: 10000,
+		RetryCount:       1,
+		RetryBaseDelayMs: 500,
+		RetryMaxDelayMs:  10000,
+		MinBalance:       "0.01",
+		MinBlockGasLimit: 5000,
+		MaxBlockGasLimit: 30000000000,
+	}
+}
+
+func TestValidateMinBalanceWei(t *testing.T) {
+	tests := []struct {
+		name          string
+		minBalanceWei string
+		wantErr       string
+	}{
+		{name: "empty", minBalanceWei: ""},
+		{name: "zero", minBalanceWei: "0"},
+		{name: "large", minBalanceWei: "1000000000000000000000000"},
+		{name: "negative", minBalanceWei: "-1", wantErr: "must not be negative"},
+		{name: "decimal", minBalanceWei: "1.5", wantErr: "invalid min_balance_wei"},
+		{name: "hex", minBalanceWei: "0x10", wantErr: "invalid min_balance_wei"},
+		{name: "not a number", minBalanceWei: "one ether", wantErr: "invalid min_balance_wei"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c := validConfig()
+			c.MinBalanceWei = tc.minBalanceWei
+			err := c.Validate()
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("error %v, want %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestMinBalanceInWei(t *testing.T) {
+	c := validConfig()
+	// min_balance in ETH is used if min_balance_wei is not set
+	if got, want := c.MinBalanceInWei(), big.NewInt(10000000000000000); got.Cmp(want) != 0 {
+		t.Errorf("MinBalanceInWei() = %s, want %s", got, want)
+	}
+
+	// min_balance_wei takes precedence over min_balance, even if lower
+	c.MinBalanceWei = "1000"
+	if got, want := c.MinBalanceInWei(), big.NewInt(1000); got.Cmp(want) != 0 {
+		t.Errorf("MinBalanceInWei() = %s, want %s", got, want)
+	}
+}
+
+// writeConfigFile writes the config yaml to a temporary file and returns its path
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	return path
+}
+
+const testConfigYaml = `
+rpc_endpoint: "http://localhost:8545"
+rich_privkey: "b9d15599650f41dc705d1edf676830117d14bf41f7a06dac5d13228507cff77f"
+timeout: "10s"
+expected_chain_id: 1
+min_balance_wei: "100"
+`
+
+func TestLoadConfigWithEnv(t *testing.T) {
+	path := writeConfigFile(t, testConfigYaml)
+	t.Setenv("RPC_ENDPOINT", "http://node.example:8545")
+	t.Setenv("RICH_PRIVKEY", "4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	t.Setenv("TIMEOUT", "30s")
+	t.Setenv("CHAIN_ID", "9000")
+	t.Setenv("MIN_BALANCE_WEI", "12345")
+
+	c, err := LoadConfigWithEnv(path)
+	if err != nil {
+		t.Fatalf("LoadConfigWithEnv failed: %v", err)
+	}
+	if c.RpcEndpoint != "http://node.example:8545" {
+		t.Errorf("rpc_endpoint %q is not overridden", c.RpcEndpoint)
+	}
+	if c.RichPrivKey != "4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318" {
+		t.Errorf("rich_privkey %q is not overridden", c.RichPrivKey)
+	}
+	if c.Timeout != "30s" {
+		t.Errorf("timeout %q is not overridden", c.Timeout)
+	}
+	if c.ExpectedChainId != 9000 {
+		t.Errorf("expected_chain_id %d is not overridden", c.ExpectedChainId)
+	}
+	if c.MinBalanceWei != "12345" {
+		t.Errorf("min_balance_wei %q is not overridden", c.MinBalanceWei)
+	}
+	// fields without environment variables keep the defaults
+	if c.ConnectTimeoutMs != 10000 {
+		t.Errorf("connect_timeout_ms %d, want default 10000", c.ConnectTimeoutMs)
+	}
+}
+
+func TestLoadConfigWithEnvFillsMissingFields(t *testing.T) {
+	// secrets are not written to the file
+	path := writeConfigFile(t, `timeout: "10s"`)
+	t.Setenv("RPC_ENDPOINT", "http://node.example:8545")
+	t.Setenv("RICH_PRIVKEY", "4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	t.Setenv("TIMEOUT", "10s")
+	t.Setenv("CHAIN_ID", "1")
+	t.Setenv("MIN_BALANCE_WEI", "")
+
+	if _, err := LoadConfigWithEnv(path); err != nil {
+		t.Fatalf("validation must run after the overrides: %v", err)
+	}
+}
+
+func TestLoadConfigWithEnvInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr string
+	}{
+		{name: "invalid CHAIN_ID", env: map[string]string{"CHAIN_ID": "mainnet"}, wantErr: "invalid CHAIN_ID"},
+		{name: "negative CHAIN_ID", env: map[string]string{"CHAIN_ID": "-1"}, wantErr: "expected_chain_id must not be negative"},
+		{name: "invalid TIMEOUT", env: map[string]string{"TIMEOUT": "ten seconds"}, wantErr: "invalid timeout"},
+		{name: "empty RPC_ENDPOINT", env: map[string]string{"RPC_ENDPOINT": ""}, wantErr: "rpc_endpoint must be set"},
+		{name: "empty RICH_PRIVKEY", env: map[string]string{"RICH_PRIVKEY": ""}, wantErr: "rich_privkey must be set"},
+		{name: "negative MIN_BALANCE_WEI", env: map[string]string{"MIN_BALANCE_WEI": "-5"}, wantErr: "min_balance_wei must not be negative"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			path := writeConfigFile(t, testConfigYaml)
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+			_, err := LoadConfigWithEnv(path)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("error %v, want %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateUpgradeBlocks(t *testing.T) {
+	tests := []struct {
+		name                            string
+		merge, shanghai, cancun, dencun uint64
+		wantErr                         string
+	}{
+		{name: "not set"},
+		{name: "in order", merge: 10, shanghai: 20, cancun: 30},
+		{name: "same block", merge: 10, shanghai: 10, cancun: 10},
+		{name: "only cancun", cancun: 30},
+		{name: "pre-Cancun", merge: 10, shanghai: 20},
+		{name: "shanghai before merge", merge: 20, shanghai: 10, wantErr: "shanghai_block must not be less than merge_block"},
+		{name: "cancun before shanghai", merge: 10, shanghai: 30, cancun: 20, wantErr: "cancun_block must not be less than"},
+		{name: "cancun before merge", merge: 30, cancun: 20, wantErr: "cancun_block must not be less than"},
+		{name: "dencun with cancun", merge: 10, shanghai: 20, cancun: 30, dencun: 30},
+		{name: "dencun before shanghai", merge: 10, shanghai: 30, dencun: 20, wantErr: "dencun_block must not be less than"},
+		{name: "dencun before merge", merge: 30, dencun: 20, wantErr: "dencun_block must not be less than"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c := validConfig()
+			c.MergeBlock, c.ShanghaiBlock, c.CancunBlock, c.DencunBlock = tc.merge, tc.shanghai, tc.cancun, tc.dencun
+			err := c.Validate()
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("error %v, want %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestMaxAttempts(t *testing.T) {
+	tests := []struct {
+		name          string
+		retryCount    int
+		methodRetries int
+		want          int
+		wantErr       string
+	}{
+		{name: "default", retryCount: 1, want: 1},
+		{name: "retry_count", retryCount: 3, want: 3},
+		{name: "method_retries", retryCount: 1, methodRetries: 2, want: 3},
+		{name: "both agree", retryCount: 3, methodRetries: 2, want: 3},
+		{name: "both conflict", retryCount: 2, methodRetries: 2, wantErr: "conflicts with method_retries"},
+		{name: "zero retry_count", retryCount: 0, wantErr: "retry_count must be at least 1"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c := validConfig()
+			c.RetryCount, c.MethodRetries = tc.retryCount, tc.methodRetries
+			err := c.Validate()
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("error %v, want %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := c.MaxAttempts(); got != tc.want {
+				t.Errorf("MaxAttempts() = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
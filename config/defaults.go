@@ -0,0 +1,74 @@
+package config
+
+// DefaultTestGroups returns the default mapping of group names to RPC method names.
+// Groups in config.yaml with the same name override these defaults.
+func DefaultTestGroups() map[string][]string {
+	return map[string][]string{
+		"basic": {
+			"eth_syncing",
+			"eth_blockNumber",
+			"eth_gasPrice",
+			"eth_maxPriorityFeePerGas",
+			"eth_chainId",
+			"net_version",
+			"web3_clientVersion",
+			"web3_sha3",
+			"eth_blobBaseFee",
+			"eth_feeHistory",
+			"eth_getBalance",
+			"eth_getBalance (zero address)",
+			"eth_getBalance (safe/finalized)",
+			"eth_getBlockByHash",
+			"eth_getBlockByNumber",
+			"eth_getBlockByNumber (pending)",
+			"eth_getBlockByNumber (earliest)",
+			"eth_getBlockByNumber (safe)",
+			"eth_getBlockByNumber (finalized)",
+			"eth_getCode (precompiles)",
+		},
+		// transactions must be sent first because the other methods query them
+		"transactions": {
+			"eth_sendRawTransaction",
+			"eth_sendRawTransaction (deploy contract)",
+			"eth_sendRawTransaction (ERC20 transfer)",
+			"eth_sendRawTransaction (mint)",
+			"eth_sendRawTransaction (self transfer)",
+			"eth_getBalance (BALANCE opcode)",
+			"eth_getBalance (SELFBALANCE opcode)",
+			"eth_getTransactionCount",
+			"eth_getBlockByNumber (single tx)",
+			"eth_getBlockByNumber (gas used pattern)",
+			"eth_getBlockReceipts",
+			"eth_getTransactionByHash",
+			"eth_getTransactionByBlockHashAndIndex",
+			"eth_getTransactionByBlockHashAndIndex (all)",
+			"eth_getTransactionByBlockNumberAndIndex",
+			"eth_getTransactionReceipt",
+			"eth_getTransactionCountByHash",
+			"eth_getBlockTransactionCountByHash",
+			"eth_getCode",
+			"eth_getCode (historical)",
+			"eth_getStorageAt",
+			"eth_getStorageAt (allowance)",
+			"eth_getProof",
+			"eth_estimateGas",
+			"eth_createAccessList",
+			"eth_call",
+		},
+		// ERC20 must be deployed first because filters are installed on its Transfer event
+		"filters": {
+			"eth_sendRawTransaction (deploy contract)",
+			"eth_sendRawTransaction (ERC20 transfer)",
+			"eth_newFilter",
+			"eth_getFilterLogs",
+			"eth_newFilter (toBlock: latest)",
+			"eth_newBlockFilter",
+			"eth_getFilterChanges",
+			"eth_uninstallFilter",
+			"eth_getLogs",
+			"eth_getLogs (blockHash with range)",
+			"eth_getLogs (blockHash with address)",
+			"eth_getLogs (multiple events)",
+		},
+	}
+}
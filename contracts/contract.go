@@ -6,3 +6,98 @@ import (
 
 //go:embed ERC20Token.bin
 var ContractByteCode []byte
+
+// BalanceCheckerByteCode is hand-written bytecode returning the balance of the address in the first
+// argument using the BALANCE opcode, regardless of the function selector. It is called as
+// getBalance(address) of BalanceChecker.abi.
+//
+// init code copies the 12-byte runtime code to memory and returns it:
+//
+//	600c    PUSH1 0x0c     runtime size
+//	80      DUP1
+//	600b    PUSH1 0x0b     runtime offset
+//	6000    PUSH1 0x00
+//	39      CODECOPY
+//	6000    PUSH1 0x00
+//	f3      RETURN
+//
+// runtime code:
+//
+//	6004    PUSH1 0x04
+//	35      CALLDATALOAD   address argument after the selector
+//	31      BALANCE
+//	6000    PUSH1 0x00
+//	52      MSTORE
+//	6020    PUSH1 0x20
+//	6000    PUSH1 0x00
+//	f3      RETURN         balance as uint256
+//
+//go:embed BalanceChecker.bin
+var BalanceCheckerByteCode []byte
+
+//go:embed BalanceChecker.abi
+var BalanceCheckerAbi string
+
+// SelfBalanceByteCode is hand-written bytecode accepting ETH, which emits an anonymous event with
+// its balance on empty calldata and returns its balance on any other calldata, both using the
+// SELFBALANCE opcode. It is called as getBalance() of SelfBalance.abi.
+//
+// init code copies the 24-byte runtime code to memory and returns it:
+//
+//	6018    PUSH1 0x18     runtime size
+//	80      DUP1
+//	600b    PUSH1 0x0b     runtime offset
+//	6000    PUSH1 0x00
+//	39      CODECOPY
+//	6000    PUSH1 0x00
+//	f3      RETURN
+//
+// runtime code:
+//
+//	36      CALLDATASIZE
+//	600e    PUSH1 0x0e
+//	57      JUMPI          to getBalance if calldata is not empty
+//	47      SELFBALANCE
+//	6000    PUSH1 0x00
+//	52      MSTORE
+//	6020    PUSH1 0x20
+//	6000    PUSH1 0x00
+//	a0      LOG0           anonymous event with the balance
+//	00      STOP
+//	5b      JUMPDEST       0x0e
+//	47      SELFBALANCE
+//	6000    PUSH1 0x00
+//	52      MSTORE
+//	6020    PUSH1 0x20
+//	6000    PUSH1 0x00
+//	f3      RETURN         balance as uint256
+//
+//go:embed SelfBalance.bin
+var SelfBalanceByteCode []byte
+
+//go:embed SelfBalance.abi
+var SelfBalanceAbi string
+
+// BalanceThresholdRuntimeByteCode is hand-written runtime bytecode returning 1 if the balance of the
+// caller is at least 1 ether, otherwise it reverts. It is injected by the code field of eth_call state
+// overrides instead of being deployed, so there is no init code.
+//
+//	670de0b6b3a7640000  PUSH8 1 ether
+//	33                  CALLER
+//	31                  BALANCE
+//	10                  LT             balance < 1 ether
+//	6019                PUSH1 0x19
+//	57                  JUMPI          to revert
+//	6001                PUSH1 0x01
+//	6000                PUSH1 0x00
+//	52                  MSTORE
+//	6020                PUSH1 0x20
+//	6000                PUSH1 0x00
+//	f3                  RETURN         1 as uint256
+//	5b                  JUMPDEST       0x19
+//	6000                PUSH1 0x00
+//	6000                PUSH1 0x00
+//	fd                  REVERT
+//
+//go:embed BalanceThreshold.bin
+var BalanceThresholdRuntimeByteCode []byte
@@ -1,12 +1,20 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	_ "embed"
 	"encoding/hex"
+	"errors"
 	"flag"
+	"fmt"
+	"io"
 	"log"
 	"os"
+	"slices"
 	"strings"
+	"text/template"
+	"time"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
@@ -21,7 +29,33 @@ import (
 func main() {
 	verbose := flag.Bool("v", false, "Enable verbose output")
 	outputExcel := flag.Bool("xlsx", false, "Save output as xlsx")
+	outputJSON := flag.Bool("json", false, "Print output as JSON instead of text")
+	outputCSV := flag.Bool("csv", false, "Print output as CSV instead of text")
+	csvPath := flag.String("csv-file", "", "Save CSV output to the file instead of printing it, used with -csv")
+	baselinePath := flag.String("compare-baseline", "", "Compare the results with a baseline saved by -json and print the changed methods")
+	junitPath := flag.String("junit", "", "Save output as JUnit XML to the file for CI pipelines")
+	strict := flag.Bool("strict", false, "Exit with code 1 on any warning as well as error")
+	minPassRate := flag.Float64("min-pass-rate", 1.0, "Exit with code 1 if the ratio of ok results is below it (0 to 1), applied only when set")
+	skip := flag.String("skip", "", "Comma-separated list of methods excluded from the test run (e.g. eth_feeHistory,eth_getProof)")
+	only := flag.String("only", "", "Comma-separated list of methods to run, others are skipped. It takes precedence over -skip")
+	failFast := flag.Bool("fail-fast", false, "Stop the test run at the first error and report the results collected so far")
+	archive := flag.Bool("archive", false, "Enable checks querying historical state, which require an archive node")
+	seed := flag.String("seed", "", "Seed for deterministic temporary accounts")
+	group := flag.String("group", "", "Run only the methods in the test group (e.g. basic, transactions, filters)")
+	timeout := flag.Duration("timeout", 0, "Deadline of the entire test run (e.g. 5m), remaining tests are skipped when exceeded. "+
+		"Unlike timeout in config.yaml, it is not a per-transaction timeout for waiting a transaction to be mined")
 	flag.Parse()
+	if *minPassRate < 0 || *minPassRate > 1 {
+		log.Fatalf("min-pass-rate must be between 0 and 1: %v", *minPassRate)
+	}
+	// warnings lower the pass rate, so the default 1.0 would make every warning fatal
+	// and -strict meaningless. The threshold is applied only when the flag is given.
+	passRateSet := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "min-pass-rate" {
+			passRateSet = true
+		}
+	})
 
 	// Load configuration from conf.yaml
 	conf := config.MustLoadConfig("config.yaml")
@@ -31,60 +65,348 @@ func main() {
 		log.Fatalf("Failed to create context: %v", err)
 	}
 
+	rCtx.Seed = *seed
+	rCtx.Archive = *archive
 	rCtx = MustLoadContractInfo(rCtx)
 
 	// Collect json rpc results
 	var results []*types.RpcResult
 
-	rpcs := []struct {
-		name types.RpcName
-		test rpc.CallRPC
-	}{
+	rpcs := []rpcTest{
+		// a syncing node silently produces wrong results, so it is flagged first
+		{rpc.EthSyncing, rpc.RpcEthSyncing},
+		// an underfunded account stops the run before sending transactions
+		{rpc.GetBalance, rpc.RpcGetBalance},
 		{rpc.SendRawTransaction, rpc.RpcSendRawTransactionTransferValue},
-		{rpc.SendRawTransaction, rpc.RpcSendRawTransactionDeployContract},
-		{rpc.SendRawTransaction, rpc.RpcSendRawTransactionTransferERC20},
+		{rpc.SendRawTransactionDeployContract, rpc.RpcSendRawTransactionDeployContract},
+		{rpc.SendRawTransactionTransferERC20, rpc.RpcSendRawTransactionTransferERC20},
+		{rpc.SendRawTransactionMintERC20, rpc.RpcSendRawTransactionMintERC20},
+		{rpc.SendRawTransactionSelfTransfer, rpc.RpcSendRawTransactionSelfTransfer},
 		{rpc.GetBlockNumber, rpc.RpcGetBlockNumber},
 		{rpc.GetGasPrice, rpc.RpcGetGasPrice},
 		{rpc.GetMaxPriorityFeePerGas, rpc.RpcGetMaxPriorityFeePerGas},
 		{rpc.GetChainId, rpc.RpcGetChainId},
-		{rpc.GetBalance, rpc.RpcGetBalance},
+		{rpc.NetVersion, rpc.RpcNetVersion},
+		{rpc.Web3ClientVersion, rpc.RpcWeb3ClientVersion},
+		{rpc.Web3Sha3, rpc.RpcWeb3Sha3},
+		{rpc.GetBlobBaseFee, rpc.RpcGetBlobBaseFee},
+		{rpc.GetFeeHistory, rpc.RpcGetFeeHistory},
+		{rpc.GetBalanceZeroAddress, rpc.RpcGetBalanceZeroAddress},
+		{rpc.GetBalanceBatch, rpc.RpcGetBalanceBatch},
+		{rpc.GetBalanceContract, rpc.RpcGetBalanceContract},
+		{rpc.GetBalanceSpecialTags, rpc.RpcGetBalanceSpecialTags},
+		{rpc.GetFinalizedState, rpc.RpcGetFinalizedState},
+		{rpc.GetBalanceOpcode, rpc.RpcGetBalanceOpcode},
+		{rpc.GetBalanceSelfBalance, rpc.RpcGetBalanceSelfBalance},
 		{rpc.GetTransactionCount, rpc.RpcGetTransactionCount},
 		{rpc.GetBlockByHash, rpc.RpcGetBlockByHash},
 		{rpc.GetBlockByNumber, rpc.RpcGetBlockByNumber},
+		{rpc.GetPendingBlock, rpc.RpcGetPendingBlock},
+		{rpc.GetEarliestBlock, rpc.RpcGetEarliestBlock},
+		{rpc.GetSafeBlock, rpc.RpcGetSafeBlock},
+		{rpc.GetFinalizedBlock, rpc.RpcGetFinalizedBlock},
+		{rpc.GetBlockWithSingleTx, rpc.RpcGetBlockWithSingleTx},
+		{rpc.GetBlockGasUsedPattern, rpc.RpcVerifyGasUsedPattern},
 		{rpc.GetBlockReceipts, rpc.RpcGetBlockReceipts},
 		{rpc.GetTransactionByHash, rpc.RpcGetTransactionByHash},
 		{rpc.GetTransactionByBlockHashAndIndex, rpc.RpcGetTransactionByBlockHashAndIndex},
+		{rpc.GetAllTransactionsByBlockHash, rpc.RpcGetAllTransactionsByBlockHash},
 		{rpc.GetTransactionByBlockNumberAndIndex, rpc.RpcGetTransactionByBlockNumberAndIndex},
 		{rpc.GetTransactionReceipt, rpc.RpcGetTransactionReceipt},
 		{rpc.GetTransactionCountByHash, rpc.RpcGetTransactionCountByHash},
 		{rpc.GetBlockTransactionCountByHash, rpc.RpcGetBlockTransactionCountByHash},
 		{rpc.GetCode, rpc.RpcGetCode},
+		{rpc.GetCodePrecompiles, rpc.RpcGetCodePrecompiles},
+		{rpc.GetCodeHistorical, rpc.RpcGetCodeHistorical},
 		{rpc.GetStorageAt, rpc.RpcGetStorageAt},
+		{rpc.GetStorageAtAllowance, rpc.RpcGetStorageAtAllowance},
+		{rpc.GetProof, rpc.RpcGetProof},
 		{rpc.NewFilter, rpc.RpcNewFilter},
 		{rpc.GetFilterLogs, rpc.RpcGetFilterLogs},
+		{rpc.NewFilterExplicitLatest, rpc.RpcNewFilterExplicitLatest},
 		{rpc.NewBlockFilter, rpc.RpcNewBlockFilter},
 		{rpc.GetFilterChanges, rpc.RpcGetFilterChanges},
 		{rpc.UninstallFilter, rpc.RpcUninstallFilter},
 		{rpc.GetLogs, rpc.RpcGetLogs},
+		{rpc.GetLogsBlockHashConflict, rpc.RpcGetLogsBlockHashConflict},
+		{rpc.GetLogsByBlockHashAndAddress, rpc.RpcGetLogsByBlockHashAndAddress},
+		{rpc.GetLogsMultiEvent, rpc.RpcGetLogsMultiEvent},
 		{rpc.EstimateGas, rpc.RpcEstimateGas},
+		{rpc.EstimateGasNoCap, rpc.RpcEstimateGasNoCap},
+		{rpc.EstimateGasDeployment, rpc.RpcEstimateGasDeployment},
+		{rpc.CreateAccessList, rpc.RpcCreateAccessList},
+		{rpc.EstimateGasAccessListComparison, rpc.RpcEstimateGasWithAccessListComparison},
 		{rpc.Call, rpc.RPCCall},
+		{rpc.CallContractCreation, rpc.RpcCallContractCreation},
+		{rpc.CallWithAccessList, rpc.RpcCallWithAccessList},
+		{rpc.CallWithLargeGas, rpc.RpcCallWithLargeGas},
+		{rpc.CallWithBalanceOverride, rpc.RpcCallWithBalanceOverride},
+		{rpc.CallWithGasPrice, rpc.RpcCallWithGasPrice},
 	}
 
+	known := make(map[types.RpcName]bool)
 	for _, r := range rpcs {
-		_, err := r.test(rCtx)
+		known[r.name] = true
+	}
+	skipSet := mustParseMethods(*skip, known)
+	onlySet := mustParseMethods(*only, known)
+
+	if *group != "" {
+		methods, ok := conf.TestGroups[*group]
+		if !ok {
+			log.Fatalf("Unknown test group: %s", *group)
+		}
+		inGroup := make(map[types.RpcName]bool)
+		for _, m := range methods {
+			inGroup[types.RpcName(m)] = true
+		}
+		filtered := rpcs[:0]
+		for _, r := range rpcs {
+			if inGroup[r.name] {
+				filtered = append(filtered, r)
+			}
+		}
+		rpcs = filtered
+	}
+
+	// -only takes precedence over -skip, and the methods not run are reported as skipped
+	var skipped []types.RpcName
+	if len(onlySet) > 0 || len(skipSet) > 0 {
+		run := func(name types.RpcName) bool { return !skipSet[name] }
+		if len(onlySet) > 0 {
+			run = func(name types.RpcName) bool { return onlySet[name] }
+		}
+		filtered := rpcs[:0]
+		for _, r := range rpcs {
+			if run(r.name) {
+				filtered = append(filtered, r)
+			} else if !slices.Contains(skipped, r.name) {
+				skipped = append(skipped, r.name)
+			}
+		}
+		rpcs = filtered
+	}
+
+	ctx := context.Background()
+	if conf.MaxTestDuration != "" {
+		maxDuration, _ := time.ParseDuration(conf.MaxTestDuration)
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, maxDuration, errMaxTestDuration)
+		defer cancel()
+	}
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadlineCause(ctx, time.Now().Add(*timeout), errTimeoutFlag)
+		defer cancel()
+	}
+
+	results = append(results, runTests(ctx, rCtx, rpcs, *failFast)...)
+	rpc.RecordTxConfirmationTimes(rCtx)
+	// report tested methods in a deterministic order
+	testedNames := make([]types.RpcName, 0, len(rCtx.TestedRPCs))
+	for name := range rCtx.TestedRPCs {
+		testedNames = append(testedNames, name)
+	}
+	slices.Sort(testedNames)
+	for _, name := range testedNames {
+		results = append(results, rCtx.TestedRPCs[name])
+	}
+	for _, name := range skipped {
+		// a skipped method may still be run as a dependency of another method
+		if rCtx.AlreadyTested(name) == nil {
+			results = append(results, &types.RpcResult{Method: name, Status: types.Skipped})
+		}
+	}
+	applyCustomValidations(results, conf.CustomValidations)
+
+	format := report.FormatText
+	if *outputExcel {
+		format = report.FormatXlsx
+	}
+	report.ReportResults(results, report.ReportOptions{
+		Verbose: *verbose,
+		Format:  format,
+		JSON:    *outputJSON,
+		CSV:     *outputCSV && *csvPath == "",
+	}, os.Stdout)
+
+	if *outputCSV && *csvPath != "" {
+		f, err := os.Create(*csvPath)
+		if err != nil {
+			log.Fatalf("Failed to create CSV report file: %v", err)
+		}
+		if err = report.CSVReport(results, f); err != nil {
+			log.Fatalf("Failed to write CSV report: %v", err)
+		}
+		f.Close()
+	}
+
+	if *junitPath != "" {
+		f, err := os.Create(*junitPath)
 		if err != nil {
+			log.Fatalf("Failed to create JUnit report file: %v", err)
+		}
+		if err = report.JUnitXMLReport(results, "ethrpc-checker", f); err != nil {
+			log.Fatalf("Failed to write JUnit report: %v", err)
+		}
+		f.Close()
+	}
+
+	if *baselinePath != "" {
+		f, err := os.Open(*baselinePath)
+		if err != nil {
+			log.Fatalf("Failed to open baseline file: %v", err)
+		}
+		baseline, err := report.LoadJSONReport(f)
+		f.Close()
+		if err != nil {
+			log.Fatalf("Failed to read baseline file: %v", err)
+		}
+		// keep stdout machine-readable for JSON and CSV output
+		var w io.Writer = os.Stdout
+		if *outputJSON || (*outputCSV && *csvPath == "") {
+			w = os.Stderr
+		}
+		report.PrintDiffs(w, types.DiffResults(baseline, results))
+	}
+
+	threshold := 0.0
+	if passRateSet {
+		threshold = *minPassRate
+	}
+	if code := exitCode(report.SummaryStats(results), *strict, threshold); code != 0 {
+		os.Exit(code)
+	}
+}
+
+// exitCode decides the exit code of the process from the result statistics.
+// It is 1 if any result is an error, any result is a warning in strict mode,
+// or the pass rate is below minPassRate.
+func exitCode(stats report.Stats, strict bool, minPassRate float64) int {
+	if stats.ErrorCount > 0 {
+		return 1
+	}
+	if strict && stats.WarningCount > 0 {
+		return 1
+	}
+	if stats.PassRate() < minPassRate {
+		return 1
+	}
+	return 0
+}
+
+// rpcTest is an entry of the RPC test table
+type rpcTest struct {
+	name types.RpcName
+	test rpc.CallRPC
+}
+
+var (
+	// errMaxTestDuration is the cause of the root context canceled by max_test_duration in config.yaml
+	errMaxTestDuration = errors.New("test run exceeded MaxTestDuration")
+	// errTimeoutFlag is the cause of the root context canceled by -timeout flag
+	errTimeoutFlag = errors.New("test run exceeded -timeout")
+)
+
+// runTests runs the RPC tests in order and returns the error results of the failed tests. Results of the
+// succeeded tests are recorded in rCtx.TestedRPCs. With failFast, it stops at the first error.
+func runTests(ctx context.Context, rCtx *rpc.RpcContext, rpcs []rpcTest, failFast bool) []*types.RpcResult {
+	var results []*types.RpcResult
+	for i, r := range rpcs {
+		if ctx.Err() != nil {
+			// record remaining methods as error, or as skipped for -timeout, and report the partial results
+			cause := context.Cause(ctx)
+			status := types.Error
+			if errors.Is(cause, errTimeoutFlag) {
+				status = types.Skipped
+			}
+			for _, remaining := range rpcs[i:] {
+				results = append(results, &types.RpcResult{
+					Method: remaining.name,
+					Status: status,
+					ErrMsg: cause.Error(),
+				})
+			}
+			break
+		}
+
+		test := rpc.WithRetry(r.test, rCtx.Conf.MaxAttempts(),
+			time.Duration(rCtx.Conf.RetryBaseDelayMs)*time.Millisecond, time.Duration(rCtx.Conf.RetryMaxDelayMs)*time.Millisecond)
+		// latency excludes the time waiting for transactions to be mined
+		start, waitedBefore := time.Now(), rCtx.TxConfirmationTime()
+		res, err := test(ctx, rCtx)
+		latency := time.Since(start) - (rCtx.TxConfirmationTime() - waitedBefore)
+		if err != nil {
+			attempts := 1
+			var retryErr *rpc.RetryError
+			if errors.As(err, &retryErr) {
+				attempts = retryErr.Attempts
+			}
 			// add error to results
 			results = append(results, &types.RpcResult{
-				Method: r.name,
-				Status: types.Error,
-				ErrMsg: err.Error(),
+				Method:   r.name,
+				Status:   types.Error,
+				ErrMsg:   err.Error(),
+				Attempts: attempts,
+				Latency:  latency,
 			})
+			if failFast || errors.Is(err, rpc.ErrInsufficientBalance) {
+				break
+			}
 			continue
 		}
+		// a result already tested by a previous method keeps its latency
+		if res.Latency == 0 {
+			res.Latency = latency
+			maxLatency := time.Duration(rCtx.Conf.MaxLatencyMs) * time.Millisecond
+			if maxLatency > 0 && latency > maxLatency && res.Status == types.Ok {
+				res.Status = types.Warning
+				res.Warnings = append(res.Warnings, fmt.Sprintf("latency %dms exceeds max_latency_ms %d", latency.Milliseconds(), rCtx.Conf.MaxLatencyMs))
+			}
+		}
+		if failFast && res.Status == types.Error {
+			break
+		}
 	}
-	results = append(results, rCtx.AlreadyTestedRPCs...)
+	return results
+}
 
-	report.ReportResults(results, *verbose, *outputExcel)
+// mustParseMethods parses a comma-separated list of method names, exiting if any of them is unknown
+func mustParseMethods(list string, known map[types.RpcName]bool) map[types.RpcName]bool {
+	methods := make(map[types.RpcName]bool)
+	if list == "" {
+		return methods
+	}
+	for _, m := range strings.Split(list, ",") {
+		name := types.RpcName(strings.TrimSpace(m))
+		if !known[name] {
+			log.Fatalf("Unknown method: %s", name)
+		}
+		methods[name] = true
+	}
+	return methods
+}
+
+// applyCustomValidations evaluates the custom validation template of each method with the result value,
+// adding a warning to the result if it does not evaluate to true
+func applyCustomValidations(results []*types.RpcResult, validations map[string]string) {
+	for _, res := range results {
+		expr, ok := validations[string(res.Method)]
+		if !ok || res.Status == types.Error {
+			continue
+		}
+		// templates are validated when the config is loaded
+		tmpl := template.Must(template.New(string(res.Method)).Parse(expr))
+		var out bytes.Buffer
+		if err := tmpl.Execute(&out, res.Value); err != nil {
+			res.Warnings = append(res.Warnings, fmt.Sprintf("custom validation failed to evaluate: %v", err))
+		} else if strings.TrimSpace(out.String()) != "true" {
+			res.Warnings = append(res.Warnings, fmt.Sprintf("custom validation %s is not satisfied", expr))
+		} else {
+			continue
+		}
+		res.Status = types.Warning
+	}
 }
 
 func MustLoadContractInfo(rCtx *rpc.RpcContext) *rpc.RpcContext {
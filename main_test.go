@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/b-harvest/ethrpc-checker/config"
+	"github.com/b-harvest/ethrpc-checker/report"
+	"github.com/b-harvest/ethrpc-checker/rpc"
+	"github.com/b-harvest/ethrpc-checker/types"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name        string
+		stats       report.Stats
+		strict      bool
+		minPassRate float64
+		want        int
+	}{
+		{
+			name:  "all ok",
+			stats: report.Stats{OkCount: 3, TotalCount: 3},
+			want:  0,
+		},
+		{
+			name:  "no results",
+			stats: report.Stats{},
+			want:  0,
+		},
+		{
+			name:  "error",
+			stats: report.Stats{OkCount: 2, ErrorCount: 1, TotalCount: 3},
+			want:  1,
+		},
+		{
+			name:        "error even with low min pass rate",
+			stats:       report.Stats{OkCount: 9, ErrorCount: 1, TotalCount: 10},
+			minPassRate: 0.5,
+			want:        1,
+		},
+		{
+			name:  "warning without strict",
+			stats: report.Stats{OkCount: 2, WarningCount: 1, TotalCount: 3},
+			want:  0,
+		},
+		{
+			name:   "warning with strict",
+			stats:  report.Stats{OkCount: 2, WarningCount: 1, TotalCount: 3},
+			strict: true,
+			want:   1,
+		},
+		{
+			name:   "skipped with strict",
+			stats:  report.Stats{OkCount: 2, SkippedCount: 1, TotalCount: 3},
+			strict: true,
+			want:   0,
+		},
+		{
+			name:        "pass rate below min pass rate",
+			stats:       report.Stats{OkCount: 8, WarningCount: 2, TotalCount: 10},
+			minPassRate: 0.9,
+			want:        1,
+		},
+		{
+			name:        "pass rate equal to min pass rate",
+			stats:       report.Stats{OkCount: 9, WarningCount: 1, TotalCount: 10},
+			minPassRate: 0.9,
+			want:        0,
+		},
+		{
+			name:        "min pass rate 1 with warning",
+			stats:       report.Stats{OkCount: 9, WarningCount: 1, TotalCount: 10},
+			minPassRate: 1,
+			want:        1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := exitCode(tc.stats, tc.strict, tc.minPassRate); got != tc.want {
+				t.Errorf("exitCode() = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func newTestContext() *rpc.RpcContext {
+	return &rpc.RpcContext{
+		Conf:                &config.Config{},
+		TestedRPCs:          make(map[types.RpcName]*types.RpcResult),
+		TxConfirmationTimes: make(map[string][]time.Duration),
+	}
+}
+
+// syntheticTests returns tests recording their invocations, where the test at failAt returns an error
+func syntheticTests(n, failAt int, invoked *[]types.RpcName) []rpcTest {
+	var tests []rpcTest
+	for i := 0; i < n; i++ {
+		name := types.RpcName(string(rune('a' + i)))
+		fail := i == failAt
+		tests = append(tests, rpcTest{name, func(_ context.Context, rCtx *rpc.RpcContext) (*types.RpcResult, error) {
+			*invoked = append(*invoked, name)
+			if fail {
+				return nil, errors.New("synthetic failure")
+			}
+			return rCtx.RecordCustomResult(name, "ok", nil), nil
+		}})
+	}
+	return tests
+}
+
+func TestRunTestsFailFast(t *testing.T) {
+	tests := []struct {
+		name        string
+		failAt      int
+		failFast    bool
+		wantInvoked int
+	}{
+		{name: "first fails with fail-fast", failAt: 0, failFast: true, wantInvoked: 1},
+		{name: "middle fails with fail-fast", failAt: 1, failFast: true, wantInvoked: 2},
+		{name: "first fails without fail-fast", failAt: 0, failFast: false, wantInvoked: 3},
+		{name: "no failure with fail-fast", failAt: -1, failFast: true, wantInvoked: 3},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rCtx := newTestContext()
+			var invoked []types.RpcName
+			results := runTests(context.Background(), rCtx, syntheticTests(3, tc.failAt, &invoked), tc.failFast)
+
+			if len(invoked) != tc.wantInvoked {
+				t.Fatalf("invoked %v, want %d tests", invoked, tc.wantInvoked)
+			}
+			wantErrors := 0
+			if tc.failAt >= 0 {
+				wantErrors = 1
+			}
+			if len(results) != wantErrors {
+				t.Fatalf("%d error results, want %d", len(results), wantErrors)
+			}
+			if wantErrors == 1 && (results[0].Status != types.Error || results[0].Method != invoked[tc.failAt]) {
+				t.Errorf("unexpected error result %+v", results[0])
+			}
+			// results collected before the failure are kept
+			if tc.failAt > 0 && rCtx.AlreadyTested(invoked[0]) == nil {
+				t.Errorf("result of %s before the failure is lost", invoked[0])
+			}
+		})
+	}
+}
+
+func TestRunTestsDeadlineExceeded(t *testing.T) {
+	tests := []struct {
+		name       string
+		cause      error
+		wantStatus types.RpcStatus
+		wantCode   int
+	}{
+		// MaxTestDuration fails the run, -timeout skips the remaining methods
+		{name: "MaxTestDuration", cause: errMaxTestDuration, wantStatus: types.Error, wantCode: 1},
+		{name: "-timeout", cause: errTimeoutFlag, wantStatus: types.Skipped, wantCode: 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancelCause(context.Background())
+			rCtx := newTestContext()
+			var invoked []types.RpcName
+			rpcs := syntheticTests(3, -1, &invoked)
+			// the deadline passes while the first test is running
+			first := rpcs[0].test
+			rpcs[0].test = func(ctx context.Context, rCtx *rpc.RpcContext) (*types.RpcResult, error) {
+				defer cancel(tc.cause)
+				return first(ctx, rCtx)
+			}
+
+			results := runTests(ctx, rCtx, rpcs, false)
+			if len(invoked) != 1 {
+				t.Fatalf("invoked %v, want only the first test", invoked)
+			}
+			if len(results) != 2 {
+				t.Fatalf("%d results, want the 2 remaining methods", len(results))
+			}
+			for i, res := range results {
+				if res.Method != rpcs[i+1].name {
+					t.Errorf("result %d: method %s, want %s", i, res.Method, rpcs[i+1].name)
+				}
+				if res.Status != tc.wantStatus {
+					t.Errorf("result %d: status %s, want %s", i, res.Status, tc.wantStatus)
+				}
+				if res.ErrMsg != tc.cause.Error() {
+					t.Errorf("result %d: errMsg %q, want %q", i, res.ErrMsg, tc.cause.Error())
+				}
+			}
+			stats := report.SummaryStats(append(results, rCtx.AlreadyTested(invoked[0])))
+			if code := exitCode(stats, false, 0); code != tc.wantCode {
+				t.Errorf("exit code %d, want %d", code, tc.wantCode)
+			}
+		})
+	}
+}
+
+func TestRunTestsBothDeadlines(t *testing.T) {
+	// the earlier deadline decides the status of the remaining methods
+	ctx, cancel := context.WithTimeoutCause(context.Background(), time.Hour, errMaxTestDuration)
+	defer cancel()
+	ctx, cancelFlag := context.WithDeadlineCause(ctx, time.Now().Add(-time.Second), errTimeoutFlag)
+	defer cancelFlag()
+
+	var invoked []types.RpcName
+	results := runTests(ctx, newTestContext(), syntheticTests(2, -1, &invoked), false)
+	if len(invoked) != 0 || len(results) != 2 {
+		t.Fatalf("invoked %v with %d results, want no test run and 2 results", invoked, len(results))
+	}
+	for _, res := range results {
+		if res.Status != types.Skipped || res.ErrMsg != errTimeoutFlag.Error() {
+			t.Errorf("unexpected result %+v", res)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package report
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/b-harvest/ethrpc-checker/types"
+)
+
+// CSVReport writes the RPC results to w as CSV with a header row.
+// Warnings are joined with semicolons, and encoding/csv quotes values containing commas or newlines.
+func CSVReport(results []*types.RpcResult, w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"method", "status", "value", "warnings", "errMsg", "latencyMs"}); err != nil {
+		return err
+	}
+	for _, result := range results {
+		value := ""
+		if result.Value != nil {
+			value = fmt.Sprintf("%v", result.Value)
+		}
+		record := []string{
+			string(result.Method),
+			string(result.Status),
+			value,
+			strings.Join(result.Warnings, ";"),
+			result.ErrMsg,
+			strconv.FormatInt(result.Latency.Milliseconds(), 10),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
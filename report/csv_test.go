@@ -0,0 +1,74 @@
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	"github.com/b-harvest/ethrpc-checker/types"
+)
+
+func TestCSVReport(t *testing.T) {
+	results := []*types.RpcResult{
+		{Method: "eth_chainId", Status: types.Ok, Value: "9000", Latency: 7 * time.Millisecond},
+		{Method: "eth_feeHistory", Status: types.Warning, Value: "a,b", Warnings: []string{"first", "second, with comma"}},
+		{Method: "eth_getProof", Status: types.Error, ErrMsg: "line one\nline two"},
+		{Method: "eth_getLogs", Status: types.Skipped, Value: "quoted \"value\""},
+	}
+
+	var buf bytes.Buffer
+	if err := CSVReport(results, &buf); err != nil {
+		t.Fatalf("CSVReport failed: %v", err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("output is not valid CSV: %v", err)
+	}
+	// header + one row per result
+	if len(records) != len(results)+1 {
+		t.Fatalf("expected %d rows, got %d", len(results)+1, len(records))
+	}
+	header := []string{"method", "status", "value", "warnings", "errMsg", "latencyMs"}
+	for i, h := range header {
+		if records[0][i] != h {
+			t.Errorf("header column %d: %q, want %q", i, records[0][i], h)
+		}
+	}
+
+	validStatus := map[string]bool{
+		string(types.Ok):      true,
+		string(types.Warning): true,
+		string(types.Error):   true,
+		string(types.Skipped): true,
+	}
+	for i, record := range records[1:] {
+		if len(record) != len(header) {
+			t.Fatalf("row %d: %d columns, want %d", i, len(record), len(header))
+		}
+		if !validStatus[record[1]] {
+			t.Errorf("row %d: invalid status %q", i, record[1])
+		}
+		if record[1] != string(results[i].Status) {
+			t.Errorf("row %d: status %q, want %q", i, record[1], results[i].Status)
+		}
+	}
+
+	// values with commas, newlines and quotes are read back as written
+	if records[2][2] != "a,b" {
+		t.Errorf("value with comma: %q", records[2][2])
+	}
+	if records[2][3] != "first;second, with comma" {
+		t.Errorf("warnings: %q", records[2][3])
+	}
+	if records[3][4] != "line one\nline two" {
+		t.Errorf("errMsg with newline: %q", records[3][4])
+	}
+	if records[4][2] != "quoted \"value\"" {
+		t.Errorf("value with quotes: %q", records[4][2])
+	}
+	if records[1][5] != "7" {
+		t.Errorf("latencyMs: %q, want 7", records[1][5])
+	}
+}
@@ -0,0 +1,82 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/b-harvest/ethrpc-checker/types"
+)
+
+// jsonResult is the JSON representation of a RPC result
+type jsonResult struct {
+	Method    types.RpcName   `json:"method"`
+	Status    types.RpcStatus `json:"status"`
+	Value     interface{}     `json:"value"`
+	Warnings  []string        `json:"warnings"`
+	ErrMsg    string          `json:"errMsg"`
+	LatencyMs int64           `json:"latencyMs"`
+}
+
+// JSONReport writes the RPC results to w as a JSON array
+func JSONReport(results []*types.RpcResult, w io.Writer) error {
+	out := make([]jsonResult, 0, len(results))
+	for _, result := range results {
+		out = append(out, jsonResult{
+			Method:    result.Method,
+			Status:    result.Status,
+			Value:     result.Value,
+			Warnings:  result.Warnings,
+			ErrMsg:    result.ErrMsg,
+			LatencyMs: result.Latency.Milliseconds(),
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// LoadJSONReport reads RPC results written by JSONReport, e.g. a baseline of a previous run
+func LoadJSONReport(r io.Reader) ([]*types.RpcResult, error) {
+	var in []jsonResult
+	if err := json.NewDecoder(r).Decode(&in); err != nil {
+		return nil, err
+	}
+	results := make([]*types.RpcResult, 0, len(in))
+	for _, result := range in {
+		results = append(results, &types.RpcResult{
+			Method:   result.Method,
+			Status:   result.Status,
+			Value:    result.Value,
+			Warnings: result.Warnings,
+			ErrMsg:   result.ErrMsg,
+			Latency:  time.Duration(result.LatencyMs) * time.Millisecond,
+		})
+	}
+	return results, nil
+}
+
+// PrintDiffs writes the methods whose status or error message changed from the baseline
+func PrintDiffs(w io.Writer, diffs []types.ResultDiff) {
+	if len(diffs) == 0 {
+		fmt.Fprintln(w, "No changes from baseline")
+		return
+	}
+	fmt.Fprintf(w, "%d changes from baseline:\n", len(diffs))
+	for _, diff := range diffs {
+		oldStatus, newStatus := diff.OldStatus, diff.NewStatus
+		if oldStatus == "" {
+			oldStatus = "(none)"
+		}
+		if newStatus == "" {
+			newStatus = "(none)"
+		}
+		fmt.Fprintf(w, "%-40s: %s -> %s", diff.Method, oldStatus, newStatus)
+		if diff.OldErr != diff.NewErr {
+			fmt.Fprintf(w, " (errMsg: %q -> %q)", diff.OldErr, diff.NewErr)
+		}
+		fmt.Fprintln(w)
+	}
+}
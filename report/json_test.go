@@ -0,0 +1,144 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/b-harvest/ethrpc-checker/types"
+)
+
+func TestJSONReport(t *testing.T) {
+	results := []*types.RpcResult{
+		{Method: "eth_chainId", Status: types.Ok, Value: "9000", Latency: 12 * time.Millisecond},
+		{Method: "eth_feeHistory", Status: types.Warning, Value: "1", Warnings: []string{"reward is all zero"}},
+		{Method: "eth_getProof", Status: types.Error, ErrMsg: "method not found"},
+		{Method: "eth_getLogs", Status: types.Skipped},
+	}
+
+	var buf bytes.Buffer
+	if err := JSONReport(results, &buf); err != nil {
+		t.Fatalf("JSONReport failed: %v", err)
+	}
+
+	var decoded []jsonResult
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if len(decoded) != len(results) {
+		t.Fatalf("expected %d results, got %d", len(results), len(decoded))
+	}
+	for i, got := range decoded {
+		want := results[i]
+		if got.Method != want.Method {
+			t.Errorf("result %d: method %q, want %q", i, got.Method, want.Method)
+		}
+		if got.Status != want.Status {
+			t.Errorf("result %d: status %q, want %q", i, got.Status, want.Status)
+		}
+		if got.ErrMsg != want.ErrMsg {
+			t.Errorf("result %d: errMsg %q, want %q", i, got.ErrMsg, want.ErrMsg)
+		}
+		if len(got.Warnings) != len(want.Warnings) {
+			t.Errorf("result %d: %d warnings, want %d", i, len(got.Warnings), len(want.Warnings))
+		}
+		if got.LatencyMs != want.Latency.Milliseconds() {
+			t.Errorf("result %d: latencyMs %d, want %d", i, got.LatencyMs, want.Latency.Milliseconds())
+		}
+	}
+	if decoded[0].Value != "9000" {
+		t.Errorf("value %v, want 9000", decoded[0].Value)
+	}
+}
+
+func TestJSONReportStatusStrings(t *testing.T) {
+	results := []*types.RpcResult{
+		{Method: "a", Status: types.Ok},
+		{Method: "b", Status: types.Warning},
+		{Method: "c", Status: types.Error},
+		{Method: "d", Status: types.Skipped},
+	}
+
+	var buf bytes.Buffer
+	if err := JSONReport(results, &buf); err != nil {
+		t.Fatalf("JSONReport failed: %v", err)
+	}
+
+	var decoded []map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	for i, want := range []string{"ok", "warning", "error", "skipped"} {
+		if decoded[i]["status"] != want {
+			t.Errorf("result %d: status %v, want %s", i, decoded[i]["status"], want)
+		}
+	}
+}
+
+func TestJSONReportEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := JSONReport(nil, &buf); err != nil {
+		t.Fatalf("JSONReport failed: %v", err)
+	}
+	if got := bytes.TrimSpace(buf.Bytes()); string(got) != "[]" {
+		t.Errorf("expected empty array, got %s", got)
+	}
+}
+
+func TestLoadJSONReport(t *testing.T) {
+	results := []*types.RpcResult{
+		{Method: "eth_chainId", Status: types.Ok, Value: "9000", Latency: 12 * time.Millisecond},
+		{Method: "eth_getProof", Status: types.Error, ErrMsg: "method not found"},
+	}
+
+	var buf bytes.Buffer
+	if err := JSONReport(results, &buf); err != nil {
+		t.Fatalf("JSONReport failed: %v", err)
+	}
+	loaded, err := LoadJSONReport(&buf)
+	if err != nil {
+		t.Fatalf("LoadJSONReport failed: %v", err)
+	}
+	if len(loaded) != len(results) {
+		t.Fatalf("expected %d results, got %d", len(results), len(loaded))
+	}
+	for i, got := range loaded {
+		if !got.Equal(results[i]) {
+			t.Errorf("result %d: got %+v, want %+v", i, got, results[i])
+		}
+		if got.Latency != results[i].Latency {
+			t.Errorf("result %d: latency %v, want %v", i, got.Latency, results[i].Latency)
+		}
+	}
+	if diffs := types.DiffResults(loaded, results); len(diffs) != 0 {
+		t.Errorf("expected no diffs against the loaded baseline, got %+v", diffs)
+	}
+}
+
+func TestLoadJSONReportInvalid(t *testing.T) {
+	if _, err := LoadJSONReport(bytes.NewBufferString("not json")); err == nil {
+		t.Error("expected an error for invalid JSON")
+	}
+}
+
+func TestPrintDiffs(t *testing.T) {
+	var buf bytes.Buffer
+	PrintDiffs(&buf, nil)
+	if !strings.Contains(buf.String(), "No changes from baseline") {
+		t.Errorf("unexpected output for no diffs: %q", buf.String())
+	}
+
+	buf.Reset()
+	PrintDiffs(&buf, []types.ResultDiff{
+		{Method: "eth_getProof", OldStatus: types.Ok, NewStatus: types.Error, NewErr: "method not found"},
+		{Method: "eth_getLogs", OldStatus: types.Ok},
+	})
+	out := buf.String()
+	for _, want := range []string{"2 changes from baseline", "eth_getProof", "ok -> error", `"method not found"`, "ok -> (none)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q:\n%s", want, out)
+		}
+	}
+}
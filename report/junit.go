@@ -0,0 +1,76 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/b-harvest/ethrpc-checker/types"
+)
+
+type junitTestSuite struct {
+	XMLName   xml.Name        `xml:"testsuite"`
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name    string        `xml:"name,attr"`
+	Time    string        `xml:"time,attr"`
+	Skipped *junitMessage `xml:"skipped,omitempty"`
+	Failure *junitMessage `xml:"failure,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+}
+
+// JUnitXMLReport writes the RPC results to w as a JUnit XML test suite.
+// Ok results pass, Warning and Skipped results are skipped and Error results fail.
+func JUnitXMLReport(results []*types.RpcResult, suiteName string, w io.Writer) error {
+	suite := junitTestSuite{
+		Name:  suiteName,
+		Tests: len(results),
+	}
+	var total float64
+	for _, result := range results {
+		seconds := result.Latency.Seconds()
+		total += seconds
+		tc := junitTestCase{
+			Name: string(result.Method),
+			Time: fmt.Sprintf("%.3f", seconds),
+		}
+		switch result.Status {
+		case types.Warning:
+			suite.Skipped++
+			tc.Skipped = &junitMessage{Message: strings.Join(result.Warnings, "; ")}
+		case types.Skipped:
+			suite.Skipped++
+			tc.Skipped = &junitMessage{Message: "excluded from the test run"}
+		case types.Error:
+			// the node returning an error or a wrong result is a failure of the test case,
+			// so errors is always 0
+			suite.Failures++
+			tc.Failure = &junitMessage{Message: result.ErrMsg}
+		}
+		suite.TestCases = append(suite.TestCases, tc)
+	}
+	suite.Time = fmt.Sprintf("%.3f", total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suite); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
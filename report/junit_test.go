@@ -0,0 +1,86 @@
+package report
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+
+	"github.com/b-harvest/ethrpc-checker/types"
+)
+
+func TestJUnitXMLReport(t *testing.T) {
+	results := []*types.RpcResult{
+		{Method: "eth_chainId", Status: types.Ok, Value: "9000"},
+		{Method: "eth_feeHistory", Status: types.Warning, Warnings: []string{"reward is all zero", "<escaped> & \"quoted\""}},
+		{Method: "eth_getProof", Status: types.Error, ErrMsg: "method not found"},
+		{Method: "eth_getLogs", Status: types.Error, ErrMsg: "log count mismatch"},
+		{Method: "eth_getCode", Status: types.Skipped},
+	}
+
+	var buf bytes.Buffer
+	if err := JUnitXMLReport(results, "ethrpc-checker", &buf); err != nil {
+		t.Fatalf("JUnitXMLReport failed: %v", err)
+	}
+
+	var suite junitTestSuite
+	if err := xml.Unmarshal(buf.Bytes(), &suite); err != nil {
+		t.Fatalf("output is not well-formed XML: %v", err)
+	}
+
+	errorCount := 0
+	for _, r := range results {
+		if r.Status == types.Error {
+			errorCount++
+		}
+	}
+	if suite.Failures != errorCount {
+		t.Errorf("failures %d, want %d", suite.Failures, errorCount)
+	}
+	if suite.Errors != 0 {
+		t.Errorf("errors %d, want 0", suite.Errors)
+	}
+	if suite.Skipped != 2 {
+		t.Errorf("skipped %d, want 2", suite.Skipped)
+	}
+	if suite.Tests != len(results) || len(suite.TestCases) != len(results) {
+		t.Fatalf("tests %d with %d test cases, want %d", suite.Tests, len(suite.TestCases), len(results))
+	}
+	if suite.Name != "ethrpc-checker" {
+		t.Errorf("suite name %q, want ethrpc-checker", suite.Name)
+	}
+
+	for i, tc := range suite.TestCases {
+		r := results[i]
+		if tc.Name != string(r.Method) {
+			t.Errorf("test case %d: name %q, want %q", i, tc.Name, r.Method)
+		}
+		switch r.Status {
+		case types.Ok:
+			if tc.Failure != nil || tc.Skipped != nil {
+				t.Errorf("test case %d: ok result must pass", i)
+			}
+		case types.Warning, types.Skipped:
+			if tc.Skipped == nil || tc.Failure != nil {
+				t.Errorf("test case %d: %s result must be skipped", i, r.Status)
+			}
+		case types.Error:
+			if tc.Failure == nil || tc.Failure.Message != r.ErrMsg {
+				t.Errorf("test case %d: error result must fail with %q", i, r.ErrMsg)
+			}
+		}
+	}
+}
+
+func TestJUnitXMLReportEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := JUnitXMLReport(nil, "empty", &buf); err != nil {
+		t.Fatalf("JUnitXMLReport failed: %v", err)
+	}
+	var suite junitTestSuite
+	if err := xml.Unmarshal(buf.Bytes(), &suite); err != nil {
+		t.Fatalf("output is not well-formed XML: %v", err)
+	}
+	if suite.Tests != 0 || suite.Failures != 0 {
+		t.Errorf("expected no tests and failures, got %d tests and %d failures", suite.Tests, suite.Failures)
+	}
+}
@@ -3,6 +3,7 @@ package report
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"time"
 
@@ -14,167 +15,250 @@ import (
 	"github.com/b-harvest/ethrpc-checker/utils"
 )
 
-// ReportResults prints or saves the RPC results based on the verbosity flag and output format
-func ReportResults(results []*types.RpcResult, verbose bool, outputExcel bool) {
-	if outputExcel {
-		f := excelize.NewFile()
-		name := fmt.Sprintf("geth%s", rpc.GethVersion)
-		if err := f.SetSheetName("Sheet1", name); err != nil {
-			log.Fatalf("Failed to set sheet name: %v", err)
-		}
+const (
+	FormatText = "text"
+	FormatXlsx = "xlsx"
+)
 
-		// set header
-		header := []string{"Method", "Status", "Value", "Warnings", "ErrMsg"}
-		for col, h := range header {
-			cell := fmt.Sprintf("%s1", string(rune('A'+col)))
-			if err := f.SetCellValue(name, cell, h); err != nil {
-				log.Fatalf("Failed to set cell value: %v", err)
-			}
+// ReportOptions configures how the RPC results are reported
+type ReportOptions struct {
+	// Verbose prints the return value of each RPC
+	Verbose bool
+	// Format is the output format (text or xlsx). xlsx saves the results to a file and prints text as well.
+	Format string
+	// ExcelPath is the path of the xlsx file. If empty, it is generated from the current time.
+	ExcelPath string
+	// JSON writes the results to w as JSON instead of text, it can be combined with xlsx format
+	JSON bool
+	// CSV writes the results to w as CSV instead of text, it can be combined with xlsx format
+	CSV bool
+}
+
+// ReportResults writes the RPC results to w and saves them as a file based on the report options
+func ReportResults(results []*types.RpcResult, opts ReportOptions, w io.Writer) {
+	if opts.Format == FormatXlsx {
+		fileName := opts.ExcelPath
+		if fileName == "" {
+			fileName = fmt.Sprintf("rpc_results_%s.xlsx", time.Now().Format("15:04:05"))
+		}
+		SaveExcel(results, fileName)
+		if !opts.JSON && !opts.CSV {
+			fmt.Fprintln(w, "Results saved to "+fileName)
 		}
+	}
 
-		// set columns width
-		if err := f.SetColWidth(name, "A", "A", 30); err != nil {
-			log.Fatalf("Failed to set col width: %v", err)
+	if opts.JSON {
+		if err := JSONReport(results, w); err != nil {
+			log.Fatalf("Failed to write JSON report: %v", err)
 		}
-		if err := f.SetColWidth(name, "C", "C", 40); err != nil {
-			log.Fatalf("Failed to set col width: %v", err)
+		return
+	}
+	if opts.CSV {
+		if err := CSVReport(results, w); err != nil {
+			log.Fatalf("Failed to write CSV report: %v", err)
 		}
-		if err := f.SetColWidth(name, "E", "E", 40); err != nil {
-			log.Fatalf("Failed to set col width: %v", err)
+		return
+	}
+
+	fmt.Fprintln(w, `
+██████╗ ██████╗  ██████╗    ██████╗ ███████╗███████╗██╗   ██╗██╗  ████████╗███████╗
+██╔══██╗██╔══██╗██╔════╝    ██╔══██╗██╔════╝██╔════╝██║   ██║██║  ╚══██╔══╝██╔════╝
+██████╔╝██████╔╝██║         ██████╔╝█████╗  ███████╗██║   ██║██║     ██║   ███████╗
+██╔══██╗██╔═══╝ ██║         ██╔══██╗██╔══╝  ╚════██║██║   ██║██║     ██║   ╚════██║
+██║  ██║██║     ╚██████╗    ██║  ██║███████╗███████║╚██████╔╝███████╗██║   ███████║
+╚═╝  ╚═╝╚═╝      ╚═════╝    ╚═╝  ╚═╝╚══════╝╚══════╝ ╚═════╝ ╚══════╝╚═╝   ╚══════╝
+------------------------------------------------------------------------------------
+                                                                                   `)
+	for _, result := range results {
+		ColorPrint(w, result, opts.Verbose)
+	}
+	PrintSummary(w, SummaryStats(results))
+}
+
+// PrintSummary prints the number of results per status and the pass rate,
+// colored by the worst status present
+func PrintSummary(w io.Writer, stats Stats) {
+	c := color.New(color.FgGreen)
+	if stats.ErrorCount > 0 {
+		c = color.New(color.FgRed)
+	} else if stats.WarningCount > 0 {
+		c = color.New(color.FgYellow)
+	}
+	skipped := ""
+	if stats.SkippedCount > 0 {
+		skipped = fmt.Sprintf(" / %d SKIPPED", stats.SkippedCount)
+	}
+	c.Fprintf(w, "\nResults: %d OK / %d WARNING / %d ERROR%s — pass rate %.1f%%\n",
+		stats.OkCount, stats.WarningCount, stats.ErrorCount, skipped, stats.PassRate()*100)
+}
+
+// SaveExcel saves the RPC results as a xlsx file
+func SaveExcel(results []*types.RpcResult, fileName string) {
+	f := excelize.NewFile()
+	name := fmt.Sprintf("geth%s", rpc.GethVersion)
+	if err := f.SetSheetName("Sheet1", name); err != nil {
+		log.Fatalf("Failed to set sheet name: %v", err)
+	}
+
+	// set header
+	header := []string{"Method", "Status", "Value", "Warnings", "ErrMsg", "Latency(ms)"}
+	for col, h := range header {
+		cell := fmt.Sprintf("%s1", string(rune('A'+col)))
+		if err := f.SetCellValue(name, cell, h); err != nil {
+			log.Fatalf("Failed to set cell value: %v", err)
 		}
+	}
+
+	// set columns width
+	if err := f.SetColWidth(name, "A", "A", 30); err != nil {
+		log.Fatalf("Failed to set col width: %v", err)
+	}
+	if err := f.SetColWidth(name, "C", "C", 40); err != nil {
+		log.Fatalf("Failed to set col width: %v", err)
+	}
+	if err := f.SetColWidth(name, "E", "E", 40); err != nil {
+		log.Fatalf("Failed to set col width: %v", err)
+	}
+
+	// set style for method column
+	methodColStyle, err := f.NewStyle(&excelize.Style{
+		Alignment: &excelize.Alignment{Vertical: "center"},
+	})
+	if err != nil {
+		log.Fatalf("Failed to create style: %v", err)
+	}
+	if err = f.SetColStyle(name, "A", methodColStyle); err != nil {
+		log.Fatalf("Failed to set col style: %v", err)
+	}
 
-		// set style for method column
-		methodColStyle, err := f.NewStyle(&excelize.Style{
-			Alignment: &excelize.Alignment{Vertical: "center"},
-		})
-		if err != nil {
-			log.Fatalf("Failed to create style: %v", err)
+	// set style for value column
+	valueColStyle, err := f.NewStyle(&excelize.Style{
+		Alignment: &excelize.Alignment{
+			WrapText:   false,
+			Horizontal: "left",
+		},
+	})
+	if err != nil {
+		log.Fatalf("Failed to create style: %v", err)
+	}
+	if err = f.SetColStyle(name, "C", valueColStyle); err != nil {
+		log.Fatalf("Failed to set col style: %v", err)
+	}
+
+	fontStyle := &excelize.Style{Font: &excelize.Font{Bold: true}}
+	for i, result := range results {
+		row := i + 2
+		warnings, _ := json.Marshal(result.Warnings)
+		methodCell := fmt.Sprintf("A%d", row)
+		if err = f.SetCellValue(name, methodCell, result.Method); err != nil {
+			log.Fatalf("Failed to set cell value: %v", err)
 		}
-		if err = f.SetColStyle(name, "A", methodColStyle); err != nil {
-			log.Fatalf("Failed to set col style: %v", err)
+		statusCell := fmt.Sprintf("B%d", row)
+		if err = f.SetCellValue(name, statusCell, result.Status); err != nil {
+			log.Fatalf("Failed to set cell value: %v", err)
 		}
-
-		// set style for value column
-		valueColStyle, err := f.NewStyle(&excelize.Style{
-			Alignment: &excelize.Alignment{
-				WrapText:   false,
-				Horizontal: "left",
-			},
-		})
-		if err != nil {
-			log.Fatalf("Failed to create style: %v", err)
+		valueCell := fmt.Sprintf("C%d", row)
+		if err = f.SetCellValue(name, valueCell, result.Value); err != nil {
+			log.Fatalf("Failed to set cell value: %v", err)
+		}
+		warningsCell := fmt.Sprintf("D%d", row)
+		if err = f.SetCellValue(name, warningsCell, string(warnings)); err != nil {
+			log.Fatalf("Failed to set cell value: %v", err)
+		}
+		errCell := fmt.Sprintf("E%d", row)
+		if err = f.SetCellValue(name, errCell, result.ErrMsg); err != nil {
+			log.Fatalf("Failed to set cell value: %v", err)
 		}
-		if err = f.SetColStyle(name, "C", valueColStyle); err != nil {
-			log.Fatalf("Failed to set col style: %v", err)
+		latencyCell := fmt.Sprintf("F%d", row)
+		if err = f.SetCellValue(name, latencyCell, result.Latency.Milliseconds()); err != nil {
+			log.Fatalf("Failed to set cell value: %v", err)
 		}
 
-		fontStyle := &excelize.Style{Font: &excelize.Font{Bold: true}}
-		for i, result := range results {
-			row := i + 2
-			warnings, _ := json.Marshal(result.Warnings)
-			methodCell := fmt.Sprintf("A%d", row)
-			if err = f.SetCellValue(name, methodCell, result.Method); err != nil {
-				log.Fatalf("Failed to set cell value: %v", err)
+		// SET STYLES
+		// set status column style based on status
+		switch result.Status {
+		case types.Ok:
+			fontStyle.Font.Color = utils.GREEN
+			s, err := f.NewStyle(fontStyle)
+			if err != nil {
+				log.Fatalf("Failed to create style: %v", err)
 			}
-			statusCell := fmt.Sprintf("B%d", row)
-			if err = f.SetCellValue(name, statusCell, result.Status); err != nil {
-				log.Fatalf("Failed to set cell value: %v", err)
+			if err = f.SetCellStyle(name, statusCell, statusCell, s); err != nil {
+				log.Fatalf("Failed to set cell style: %v", err)
 			}
-			valueCell := fmt.Sprintf("C%d", row)
-			if err = f.SetCellValue(name, valueCell, result.Value); err != nil {
-				log.Fatalf("Failed to set cell value: %v", err)
+		case types.Warning:
+			fontStyle.Font.Color = utils.YELLOW
+			s, err := f.NewStyle(fontStyle)
+			if err != nil {
+				log.Fatalf("Failed to create style: %v", err)
 			}
-			warningsCell := fmt.Sprintf("D%d", row)
-			if err = f.SetCellValue(name, warningsCell, string(warnings)); err != nil {
-				log.Fatalf("Failed to set cell value: %v", err)
+			if err = f.SetCellStyle(name, statusCell, statusCell, s); err != nil {
+				log.Fatalf("Failed to set cell style: %v", err)
 			}
-			errCell := fmt.Sprintf("E%d", row)
-			if err = f.SetCellValue(name, errCell, result.ErrMsg); err != nil {
-				log.Fatalf("Failed to set cell value: %v", err)
+		case types.Error:
+			fontStyle.Font.Color = utils.RED
+			s, err := f.NewStyle(fontStyle)
+			if err != nil {
+				log.Fatalf("Failed to create style: %v", err)
 			}
-
-			// SET STYLES
-			// set status column style based on status
-			switch result.Status {
-			case types.Ok:
-				fontStyle.Font.Color = utils.GREEN
-				s, err := f.NewStyle(fontStyle)
-				if err != nil {
-					log.Fatalf("Failed to create style: %v", err)
-				}
-				if err = f.SetCellStyle(name, statusCell, statusCell, s); err != nil {
-					log.Fatalf("Failed to set cell style: %v", err)
-				}
-			case types.Warning:
-				fontStyle.Font.Color = utils.YELLOW
-				s, err := f.NewStyle(fontStyle)
-				if err != nil {
-					log.Fatalf("Failed to create style: %v", err)
-				}
-				if err = f.SetCellStyle(name, statusCell, statusCell, s); err != nil {
-					log.Fatalf("Failed to set cell style: %v", err)
-				}
-			case types.Error:
-				fontStyle.Font.Color = utils.RED
-				s, err := f.NewStyle(fontStyle)
-				if err != nil {
-					log.Fatalf("Failed to create style: %v", err)
-				}
-				if err = f.SetCellStyle(name, statusCell, statusCell, s); err != nil {
-					log.Fatalf("Failed to set cell style: %v", err)
-				}
+			if err = f.SetCellStyle(name, statusCell, statusCell, s); err != nil {
+				log.Fatalf("Failed to set cell style: %v", err)
 			}
-
-			if err = f.SetRowHeight(name, row, 20); err != nil {
-				log.Fatalf("Failed to set row height: %v", err)
+		case types.Skipped:
+			fontStyle.Font.Color = utils.GREY
+			s, err := f.NewStyle(fontStyle)
+			if err != nil {
+				log.Fatalf("Failed to create style: %v", err)
+			}
+			if err = f.SetCellStyle(name, statusCell, statusCell, s); err != nil {
+				log.Fatalf("Failed to set cell style: %v", err)
 			}
-		}
-		// Set header style at last to avoid override by other styles
-		headerStyle, err := f.NewStyle(&excelize.Style{
-			Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"#D3D3D3"}},
-			Font: &excelize.Font{Bold: true},
-		})
-		if err != nil {
-			log.Fatalf("Failed to create style: %v", err)
-		}
-		if err = f.SetRowStyle(name, 1, 1, headerStyle); err != nil {
-			log.Fatalf("Failed to set row style: %v", err)
 		}
 
-		fileName := fmt.Sprintf("rpc_results_%s.xlsx", time.Now().Format("15:04:05"))
-		if err := f.SaveAs(fileName); err != nil {
-			log.Fatalf("Failed to save Excel file: %v", err)
+		if err = f.SetRowHeight(name, row, 20); err != nil {
+			log.Fatalf("Failed to set row height: %v", err)
 		}
-		fmt.Println("Results saved to " + fileName)
+	}
+	// Set header style at last to avoid override by other styles
+	headerStyle, err := f.NewStyle(&excelize.Style{
+		Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"#D3D3D3"}},
+		Font: &excelize.Font{Bold: true},
+	})
+	if err != nil {
+		log.Fatalf("Failed to create style: %v", err)
+	}
+	if err = f.SetRowStyle(name, 1, 1, headerStyle); err != nil {
+		log.Fatalf("Failed to set row style: %v", err)
 	}
 
-	fmt.Println(`
-██████╗ ██████╗  ██████╗    ██████╗ ███████╗███████╗██╗   ██╗██╗  ████████╗███████╗
-██╔══██╗██╔══██╗██╔════╝    ██╔══██╗██╔════╝██╔════╝██║   ██║██║  ╚══██╔══╝██╔════╝
-██████╔╝██████╔╝██║         ██████╔╝█████╗  ███████╗██║   ██║██║     ██║   ███████╗
-██╔══██╗██╔═══╝ ██║         ██╔══██╗██╔══╝  ╚════██║██║   ██║██║     ██║   ╚════██║
-██║  ██║██║     ╚██████╗    ██║  ██║███████╗███████║╚██████╔╝███████╗██║   ███████║
-╚═╝  ╚═╝╚═╝      ╚═════╝    ╚═╝  ╚═╝╚══════╝╚══════╝ ╚═════╝ ╚══════╝╚═╝   ╚══════╝
-------------------------------------------------------------------------------------
-                                                                                   `)
-	for _, result := range results {
-		ColorPrint(result, verbose)
+	if err := f.SaveAs(fileName); err != nil {
+		log.Fatalf("Failed to save Excel file: %v", err)
 	}
 }
 
-func ColorPrint(result *types.RpcResult, verbose bool) {
+func ColorPrint(w io.Writer, result *types.RpcResult, verbose bool) {
 	method := result.Method
 	status := result.Status
+	// latency and retries are printed in verbose mode only
+	latency := ""
+	if verbose {
+		latency = fmt.Sprintf(" [%dms]", result.Latency.Milliseconds())
+		if result.Attempts > 1 {
+			latency += fmt.Sprintf(" [%d attempts]", result.Attempts)
+		}
+	}
 	switch status {
 	case types.Ok:
 		value := result.Value
 		if !verbose {
 			value = ""
 		}
-		color.Green("%-40s: %s (value: %v)", method, status, value)
+		color.New(color.FgGreen).Fprintf(w, "%-40s: %s (value: %v)%s\n", method, status, value, latency)
 	case types.Warning:
-		color.Yellow("%-40s: %s (%v)", method, status, result.Warnings)
+		color.New(color.FgYellow).Fprintf(w, "%-40s: %s (%v)%s\n", method, status, result.Warnings, latency)
 	case types.Error:
-		color.Red("%-40s: %s (%v)", method, status, result.ErrMsg)
+		color.New(color.FgRed).Fprintf(w, "%-40s: %s (%v)%s\n", method, status, result.ErrMsg, latency)
+	case types.Skipped:
+		color.New(color.FgHiBlack).Fprintf(w, "%-40s: %s\n", method, status)
 	}
 }
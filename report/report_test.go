@@ -0,0 +1,101 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/xuri/excelize/v2"
+
+	"github.com/b-harvest/ethrpc-checker/rpc"
+	"github.com/b-harvest/ethrpc-checker/types"
+)
+
+var testResults = []*types.RpcResult{
+	{Method: "eth_chainId", Status: types.Ok, Value: "9000"},
+	{Method: "eth_feeHistory", Status: types.Warning, Warnings: []string{"reward is all zero"}},
+	{Method: "eth_getProof", Status: types.Error, ErrMsg: "method not found"},
+	{Method: "eth_getLogs", Status: types.Skipped},
+}
+
+func countLines(s string) int {
+	return len(strings.Split(strings.TrimRight(s, "\n"), "\n"))
+}
+
+func TestReportResultsText(t *testing.T) {
+	color.NoColor = true
+
+	// the banner and the summary are printed regardless of the results
+	var empty bytes.Buffer
+	ReportResults(nil, ReportOptions{Format: FormatText}, &empty)
+
+	for _, verbose := range []bool{false, true} {
+		var buf bytes.Buffer
+		ReportResults(testResults, ReportOptions{Verbose: verbose, Format: FormatText}, &buf)
+		if got, want := countLines(buf.String()), countLines(empty.String())+len(testResults); got != want {
+			t.Errorf("verbose %v: %d lines, want %d", verbose, got, want)
+		}
+		for _, r := range testResults {
+			if !strings.Contains(buf.String(), string(r.Method)) {
+				t.Errorf("verbose %v: %s is not printed", verbose, r.Method)
+			}
+		}
+		if !strings.Contains(buf.String(), "Results: 1 OK / 1 WARNING / 1 ERROR / 1 SKIPPED") {
+			t.Errorf("verbose %v: summary is not printed:\n%s", verbose, buf.String())
+		}
+	}
+}
+
+func TestReportResultsJSON(t *testing.T) {
+	var buf bytes.Buffer
+	ReportResults(testResults, ReportOptions{Format: FormatText, JSON: true}, &buf)
+	// one "method" line per result in the indented JSON array
+	if got := strings.Count(buf.String(), `"method":`); got != len(testResults) {
+		t.Errorf("%d results, want %d", got, len(testResults))
+	}
+	if strings.Contains(buf.String(), "Results:") {
+		t.Error("summary must not be printed with JSON output")
+	}
+}
+
+func TestReportResultsCSV(t *testing.T) {
+	var buf bytes.Buffer
+	ReportResults(testResults, ReportOptions{Format: FormatText, CSV: true}, &buf)
+	if got, want := countLines(buf.String()), len(testResults)+1; got != want {
+		t.Errorf("%d lines, want %d", got, want)
+	}
+}
+
+func TestReportResultsXlsx(t *testing.T) {
+	color.NoColor = true
+	path := filepath.Join(t.TempDir(), "results.xlsx")
+
+	var text bytes.Buffer
+	ReportResults(nil, ReportOptions{Format: FormatText}, &text)
+	var buf bytes.Buffer
+	ReportResults(testResults, ReportOptions{Format: FormatXlsx, ExcelPath: path}, &buf)
+	// the text results are printed with the saved file name
+	if got, want := countLines(buf.String()), countLines(text.String())+len(testResults)+1; got != want {
+		t.Errorf("%d lines, want %d", got, want)
+	}
+	if !strings.Contains(buf.String(), "Results saved to "+path) {
+		t.Errorf("saved file name is not printed")
+	}
+
+	f, err := excelize.OpenFile(path)
+	if err != nil {
+		t.Fatalf("failed to open xlsx: %v", err)
+	}
+	defer f.Close()
+	rows, err := f.GetRows(fmt.Sprintf("geth%s", rpc.GethVersion))
+	if err != nil {
+		t.Fatalf("failed to read rows: %v", err)
+	}
+	// header + one row per result
+	if len(rows) != len(testResults)+1 {
+		t.Errorf("%d rows, want %d", len(rows), len(testResults)+1)
+	}
+}
@@ -0,0 +1,43 @@
+package report
+
+import (
+	"github.com/b-harvest/ethrpc-checker/types"
+)
+
+// Stats is the number of RPC results per status
+type Stats struct {
+	OkCount      int
+	WarningCount int
+	ErrorCount   int
+	SkippedCount int
+	TotalCount   int
+}
+
+// SummaryStats counts the RPC results per status
+func SummaryStats(results []*types.RpcResult) Stats {
+	var stats Stats
+	for _, result := range results {
+		switch result.Status {
+		case types.Ok:
+			stats.OkCount++
+		case types.Warning:
+			stats.WarningCount++
+		case types.Error:
+			stats.ErrorCount++
+		case types.Skipped:
+			stats.SkippedCount++
+		}
+		stats.TotalCount++
+	}
+	return stats
+}
+
+// PassRate returns the ratio of Ok results to all results except skipped ones.
+// It is 1 if there is no result.
+func (s Stats) PassRate() float64 {
+	tested := s.TotalCount - s.SkippedCount
+	if tested == 0 {
+		return 1
+	}
+	return float64(s.OkCount) / float64(tested)
+}
@@ -0,0 +1,74 @@
+package report
+
+import (
+	"math"
+	"testing"
+
+	"github.com/b-harvest/ethrpc-checker/types"
+)
+
+func resultsWithStatus(statuses ...types.RpcStatus) []*types.RpcResult {
+	results := make([]*types.RpcResult, 0, len(statuses))
+	for _, status := range statuses {
+		results = append(results, &types.RpcResult{Method: "eth_test", Status: status})
+	}
+	return results
+}
+
+func TestSummaryStats(t *testing.T) {
+	tests := []struct {
+		name     string
+		results  []*types.RpcResult
+		want     Stats
+		passRate float64
+	}{
+		{
+			name:     "empty",
+			results:  nil,
+			want:     Stats{},
+			passRate: 1,
+		},
+		{
+			name:     "all ok",
+			results:  resultsWithStatus(types.Ok, types.Ok, types.Ok),
+			want:     Stats{OkCount: 3, TotalCount: 3},
+			passRate: 1,
+		},
+		{
+			name:     "all error",
+			results:  resultsWithStatus(types.Error, types.Error),
+			want:     Stats{ErrorCount: 2, TotalCount: 2},
+			passRate: 0,
+		},
+		{
+			name:     "mixed",
+			results:  resultsWithStatus(types.Ok, types.Ok, types.Ok, types.Warning, types.Error),
+			want:     Stats{OkCount: 3, WarningCount: 1, ErrorCount: 1, TotalCount: 5},
+			passRate: 0.6,
+		},
+		{
+			name:     "skipped results are excluded from pass rate",
+			results:  resultsWithStatus(types.Ok, types.Warning, types.Skipped, types.Skipped),
+			want:     Stats{OkCount: 1, WarningCount: 1, SkippedCount: 2, TotalCount: 4},
+			passRate: 0.5,
+		},
+		{
+			name:     "all skipped",
+			results:  resultsWithStatus(types.Skipped),
+			want:     Stats{SkippedCount: 1, TotalCount: 1},
+			passRate: 1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := SummaryStats(tc.results)
+			if got != tc.want {
+				t.Errorf("SummaryStats() = %+v, want %+v", got, tc.want)
+			}
+			if rate := got.PassRate(); math.Abs(rate-tc.passRate) > 1e-9 {
+				t.Errorf("PassRate() = %v, want %v", rate, tc.passRate)
+			}
+		})
+	}
+}
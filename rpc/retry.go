@@ -0,0 +1,94 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/b-harvest/ethrpc-checker/types"
+)
+
+const (
+	errCodeMethodNotFound = -32601
+	errCodeLimitExceeded  = -32005
+)
+
+// RetryError is returned by a CallRPC wrapped by WithRetry when all attempts fail
+type RetryError struct {
+	Err      error
+	Attempts int
+}
+
+func (e *RetryError) Error() string {
+	if e.Attempts <= 1 {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%v (after %d attempts)", e.Err, e.Attempts)
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// WithRetry wraps fn to retry it up to maxAttempts times in total on transient errors.
+// It waits baseDelay * 2^attempt between attempts, capped by maxDelay, and records
+// the number of attempts in the result unless it was already recorded by an earlier test.
+func WithRetry(fn CallRPC, maxAttempts int, baseDelay, maxDelay time.Duration) CallRPC {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	return func(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+		var err error
+		for attempt := 0; attempt < maxAttempts; attempt++ {
+			if attempt > 0 {
+				delay := baseDelay << (attempt - 1)
+				if delay > maxDelay || delay <= 0 {
+					delay = maxDelay
+				}
+				select {
+				case <-ctx.Done():
+					return nil, &RetryError{Err: err, Attempts: attempt}
+				case <-time.After(delay):
+				}
+			}
+			var res *types.RpcResult
+			res, err = fn(ctx, rCtx)
+			if err == nil {
+				// a result cached by AlreadyTested keeps the attempts of the test which recorded it
+				if res.Attempts == 0 {
+					res.Attempts = attempt + 1
+				}
+				return res, nil
+			}
+			if !isTransientError(err) {
+				return nil, &RetryError{Err: err, Attempts: attempt + 1}
+			}
+		}
+		return nil, &RetryError{Err: err, Attempts: maxAttempts}
+	}
+}
+
+// isTransientError reports whether err is caused by the transport or rate limiting.
+// Errors returned by the node, e.g. method not found or invalid params, are not retried.
+func isTransientError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) {
+		return rpcErr.ErrorCode() == errCodeLimitExceeded
+	}
+	var httpErr rpc.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode == http.StatusTooManyRequests || httpErr.StatusCode >= http.StatusInternalServerError
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.EOF) || strings.Contains(err.Error(), "connection")
+}
@@ -0,0 +1,127 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/b-harvest/ethrpc-checker/types"
+)
+
+// flakyTest fails with err until it is called failures times
+func flakyTest(failures int, err error, calls *int) CallRPC {
+	return func(_ context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+		*calls++
+		if *calls <= failures {
+			return nil, err
+		}
+		return rCtx.RecordCustomResult("test", "ok", nil), nil
+	}
+}
+
+func newRetryTestContext() *RpcContext {
+	return &RpcContext{TestedRPCs: make(map[types.RpcName]*types.RpcResult)}
+}
+
+func TestWithRetry(t *testing.T) {
+	tests := []struct {
+		name         string
+		failures     int
+		err          error
+		maxAttempts  int
+		wantCalls    int
+		wantAttempts int
+		wantErr      bool
+	}{
+		{name: "success", failures: 0, err: io.EOF, maxAttempts: 3, wantCalls: 1, wantAttempts: 1},
+		{name: "transient error retried", failures: 2, err: io.EOF, maxAttempts: 3, wantCalls: 3, wantAttempts: 3},
+		{name: "attempts exhausted", failures: 5, err: io.EOF, maxAttempts: 3, wantCalls: 3, wantAttempts: 3, wantErr: true},
+		{name: "node error not retried", failures: 5, err: errors.New("invalid params"), maxAttempts: 3, wantCalls: 1, wantAttempts: 1, wantErr: true},
+		{name: "no retry", failures: 1, err: io.EOF, maxAttempts: 1, wantCalls: 1, wantAttempts: 1, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			test := WithRetry(flakyTest(tc.failures, tc.err, &calls), tc.maxAttempts, time.Millisecond, time.Millisecond)
+			res, err := test(context.Background(), newRetryTestContext())
+			if calls != tc.wantCalls {
+				t.Errorf("called %d times, want %d", calls, tc.wantCalls)
+			}
+			if tc.wantErr {
+				var retryErr *RetryError
+				if !errors.As(err, &retryErr) {
+					t.Fatalf("error %v, want RetryError", err)
+				}
+				if retryErr.Attempts != tc.wantAttempts {
+					t.Errorf("%d attempts, want %d", retryErr.Attempts, tc.wantAttempts)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if res.Attempts != tc.wantAttempts {
+				t.Errorf("%d attempts, want %d", res.Attempts, tc.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestWithRetryMaxDelay(t *testing.T) {
+	calls := 0
+	// the base delay is capped by the max delay
+	test := WithRetry(flakyTest(2, io.EOF, &calls), 3, time.Hour, 5*time.Millisecond)
+
+	start := time.Now()
+	if _, err := test(context.Background(), newRetryTestContext()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("retries took %v, the max delay is not applied", elapsed)
+	}
+}
+
+func TestWithRetryCachedResult(t *testing.T) {
+	rCtx := newRetryTestContext()
+	cached := rCtx.RecordCustomResult("test", "ok", nil)
+	cached.Attempts = 3
+
+	// a test returning the result cached by an earlier test must not overwrite its attempts
+	test := WithRetry(func(_ context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+		return rCtx.AlreadyTested("test"), nil
+	}, 2, time.Millisecond, time.Millisecond)
+	res, err := test(context.Background(), rCtx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Attempts != 3 {
+		t.Errorf("%d attempts, want the 3 attempts of the cached result", res.Attempts)
+	}
+}
+
+func TestIsTransientError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"EOF", io.EOF, true},
+		{"connection refused", errors.New("dial tcp: connection refused"), true},
+		{"too many requests", rpc.HTTPError{StatusCode: 429}, true},
+		{"bad gateway", rpc.HTTPError{StatusCode: 502}, true},
+		{"not found", rpc.HTTPError{StatusCode: 404}, false},
+		{"canceled", context.Canceled, false},
+		{"deadline exceeded", context.DeadlineExceeded, false},
+		{"node error", errors.New("execution reverted"), false},
+	}
+	for _, tt := range tests {
+		if got := isTransientError(tt.err); got != tt.want {
+			t.Errorf("isTransientError(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
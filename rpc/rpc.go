@@ -1,22 +1,32 @@
 package rpc
 
 import (
+	"bytes"
 	"context"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
+	"math"
 	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/lru"
 	gethtypes "github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/rpc"
-	"github.com/google/go-cmp/cmp"
+	"github.com/ethereum/go-ethereum/trie"
 	"github.com/status-im/keycard-go/hexutils"
 
 	"github.com/b-harvest/ethrpc-checker/config"
@@ -29,92 +39,268 @@ import (
 // Update it when go-ethereum of go.mod is updated
 const GethVersion = "1.14.7"
 
-type CallRPC func(rCtx *RpcContext) (*types.RpcResult, error)
+type CallRPC func(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error)
 
 const (
+	EthSyncing                          types.RpcName = "eth_syncing"
 	SendRawTransaction                  types.RpcName = "eth_sendRawTransaction"
+	SendRawTransactionDeployContract    types.RpcName = "eth_sendRawTransaction (deploy contract)"
+	SendRawTransactionTransferERC20     types.RpcName = "eth_sendRawTransaction (ERC20 transfer)"
+	SendRawTransactionMintERC20         types.RpcName = "eth_sendRawTransaction (mint)"
+	SendRawTransactionSelfTransfer      types.RpcName = "eth_sendRawTransaction (self transfer)"
 	GetBlockNumber                      types.RpcName = "eth_blockNumber"
 	GetGasPrice                         types.RpcName = "eth_gasPrice"
 	GetMaxPriorityFeePerGas             types.RpcName = "eth_maxPriorityFeePerGas"
 	GetChainId                          types.RpcName = "eth_chainId"
+	GetBlobBaseFee                      types.RpcName = "eth_blobBaseFee"
+	GetFeeHistory                       types.RpcName = "eth_feeHistory"
+	NetVersion                          types.RpcName = "net_version"
+	Web3ClientVersion                   types.RpcName = "web3_clientVersion"
+	Web3Sha3                            types.RpcName = "web3_sha3"
 	GetBalance                          types.RpcName = "eth_getBalance"
+	GetBalanceZeroAddress               types.RpcName = "eth_getBalance (zero address)"
+	GetBalanceBatch                     types.RpcName = "eth_getBalance (batch)"
+	GetBalanceContract                  types.RpcName = "eth_getBalance (contract)"
+	GetBalanceSpecialTags               types.RpcName = "eth_getBalance (safe/finalized)"
+	GetFinalizedState                   types.RpcName = "eth_getBalance/eth_getTransactionCount (finalized)"
+	GetBalanceOpcode                    types.RpcName = "eth_getBalance (BALANCE opcode)"
+	GetBalanceSelfBalance               types.RpcName = "eth_getBalance (SELFBALANCE opcode)"
+	GetBalanceAfterTransfer             types.RpcName = "eth_getBalance (after transfer)"
 	GetBlockByHash                      types.RpcName = "eth_getBlockByHash"
 	GetBlockByNumber                    types.RpcName = "eth_getBlockByNumber"
+	GetPendingBlock                     types.RpcName = "eth_getBlockByNumber (pending)"
+	GetPendingBlockParentHash           types.RpcName = "eth_getBlockByNumber (pending parentHash)"
+	GetEarliestBlock                    types.RpcName = "eth_getBlockByNumber (earliest)"
+	GetSafeBlock                        types.RpcName = "eth_getBlockByNumber (safe)"
+	GetFinalizedBlock                   types.RpcName = "eth_getBlockByNumber (finalized)"
+	GetBlockWithSingleTx                types.RpcName = "eth_getBlockByNumber (single tx)"
+	GetBlockGasUsedPattern              types.RpcName = "eth_getBlockByNumber (gas used pattern)"
 	GetBlockReceipts                    types.RpcName = "eth_getBlockReceipts"
 	GetTransactionByHash                types.RpcName = "eth_getTransactionByHash"
+	GetTransactionByHashPending         types.RpcName = "eth_getTransactionByHash (pending)"
 	GetTransactionByBlockHashAndIndex   types.RpcName = "eth_getTransactionByBlockHashAndIndex"
 	GetTransactionByBlockNumberAndIndex types.RpcName = "eth_getTransactionByBlockNumberAndIndex"
+	GetAllTransactionsByBlockHash       types.RpcName = "eth_getTransactionByBlockHashAndIndex (all)"
 	GetTransactionReceipt               types.RpcName = "eth_getTransactionReceipt"
 	GetTransactionCount                 types.RpcName = "eth_getTransactionCount"
 	GetTransactionCountByHash           types.RpcName = "eth_getTransactionCountByHash"
 	GetBlockTransactionCountByHash      types.RpcName = "eth_getBlockTransactionCountByHash"
 	GetCode                             types.RpcName = "eth_getCode"
+	GetCodePrecompiles                  types.RpcName = "eth_getCode (precompiles)"
+	GetCodeHistorical                   types.RpcName = "eth_getCode (historical)"
 	GetStorageAt                        types.RpcName = "eth_getStorageAt"
+	GetStorageAtAllowance               types.RpcName = "eth_getStorageAt (allowance)"
+	GetProof                            types.RpcName = "eth_getProof"
 	NewFilter                           types.RpcName = "eth_newFilter"
+	NewFilterExplicitLatest             types.RpcName = "eth_newFilter (toBlock: latest)"
 	GetFilterLogs                       types.RpcName = "eth_getFilterLogs"
 	NewBlockFilter                      types.RpcName = "eth_newBlockFilter"
 	GetFilterChanges                    types.RpcName = "eth_getFilterChanges"
 	UninstallFilter                     types.RpcName = "eth_uninstallFilter"
 	GetLogs                             types.RpcName = "eth_getLogs"
+	GetLogsBlockHashConflict            types.RpcName = "eth_getLogs (blockHash with range)"
+	GetLogsMultiEvent                   types.RpcName = "eth_getLogs (multiple events)"
+	GetLogsByBlockHashAndAddress        types.RpcName = "eth_getLogs (blockHash with address)"
 	EstimateGas                         types.RpcName = "eth_estimateGas"
+	EstimateGasNoCap                    types.RpcName = "eth_estimateGas (gas: 0)"
+	EstimateGasDeployment               types.RpcName = "eth_estimateGas (deployment)"
+	EstimateGasAccessListComparison     types.RpcName = "eth_estimateGas (access list)"
+	CreateAccessList                    types.RpcName = "eth_createAccessList"
 	Call                                types.RpcName = "eth_call"
+	CallContractCreation                types.RpcName = "eth_call (contract creation)"
+	CallWithAccessList                  types.RpcName = "eth_call (access list)"
+	CallWithLargeGas                    types.RpcName = "eth_call (max gas)"
+	CallWithBalanceOverride             types.RpcName = "eth_call (balance override)"
+	CallWithGasPrice                    types.RpcName = "eth_call (gasPrice)"
+	TxConfirmationTimes                 types.RpcName = "eth_getTransactionReceipt (confirmation time)"
 )
 
 type RpcContext struct {
 	Conf                  *config.Config
 	EthCli                *ethclient.Client
+	EthCliWs              *ethclient.Client
 	Acc                   *types.Account
 	ChainId               *big.Int
 	MaxPriorityFeePerGas  *big.Int
 	GasPrice              *big.Int
 	ProcessedTransactions []common.Hash
 	BlockNumsIncludingTx  []uint64
-	AlreadyTestedRPCs     []*types.RpcResult
-	ERC20Abi              *abi.ABI
-	ERC20ByteCode         []byte
-	ERC20Addr             common.Address
-	FilterQuery           ethereum.FilterQuery
-	FilterId              string
-	BlockFilterId         string
+	// TestedRPCs maps each tested method to its result
+	TestedRPCs          map[types.RpcName]*types.RpcResult
+	ERC20Abi            *abi.ABI
+	ERC20ByteCode       []byte
+	ERC20Addr           common.Address
+	ERC20DeployBlockNum uint64
+	ERC20DeployReceipt  *gethtypes.Receipt
+	LastBlockReceipts   gethtypes.Receipts
+	// BlockCache caches blocks fetched by number to reduce redundant eth_getBlockByNumber calls
+	BlockCache    *lru.Cache[uint64, *gethtypes.Block]
+	FilterQuery   ethereum.FilterQuery
+	FilterId      string
+	BlockFilterId string
+	// TxConfirmationTimes records the time-to-confirmation of transactions per WaitForTx strategy
+	TxConfirmationTimes map[string][]time.Duration
+	// TxWaitTime is the total time spent waiting for transactions to be mined
+	TxWaitTime time.Duration
+	// Seed makes temporary accounts deterministic if set
+	Seed string
+	// Archive enables checks querying historical state, which require an archive node
+	Archive bool
 }
 
 func NewContext(conf *config.Config) (*RpcContext, error) {
 	// Connect to the Ethereum client
-	ethCli, err := ethclient.Dial(conf.RpcEndpoint)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(conf.ConnectTimeoutMs)*time.Millisecond)
+	defer cancel()
+	rpcCli, err := rpc.DialContext(ctx, conf.RpcEndpoint)
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("failed to connect to RPC endpoint within %dms: %w", conf.ConnectTimeoutMs, err)
+		}
+		return nil, err
+	}
+	ethCli := ethclient.NewClient(rpcCli)
+	// dialing HTTP endpoints is lazy, so make a call to check the endpoint is reachable within the timeout
+	chainId, err := ethCli.ChainID(ctx)
 	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("failed to connect to RPC endpoint within %dms: %w", conf.ConnectTimeoutMs, err)
+		}
 		return nil, err
 	}
+	// abort before any transaction is signed for a wrong network
+	if conf.ExpectedChainId != 0 && chainId.Cmp(big.NewInt(conf.ExpectedChainId)) != 0 {
+		return nil, fmt.Errorf("chainId %s of RPC endpoint does not match expected_chain_id %d", chainId, conf.ExpectedChainId)
+	}
+
+	// the websocket endpoint is used only to wait for transactions by subscription
+	var ethCliWs *ethclient.Client
+	if conf.UseSubscriptionForTx && conf.WsEndpoint != "" {
+		wsCli, err := rpc.DialContext(ctx, conf.WsEndpoint)
+		if err != nil {
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return nil, fmt.Errorf("failed to connect to WS endpoint within %dms: %w", conf.ConnectTimeoutMs, err)
+			}
+			return nil, err
+		}
+		ethCliWs = ethclient.NewClient(wsCli)
+	}
 
 	ecdsaPrivKey, err := crypto.HexToECDSA(conf.RichPrivKey)
 	if err != nil {
 		return nil, err
 	}
 
+	// check the rich account has enough balance to send transactions
+	addr := crypto.PubkeyToAddress(ecdsaPrivKey.PublicKey)
+	minBalance := conf.MinBalanceInWei()
+	balance, err := ethCli.BalanceAt(ctx, addr, nil)
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("failed to get balance of the rich account within %dms: %w", conf.ConnectTimeoutMs, err)
+		}
+		return nil, err
+	}
+	if balance.Cmp(minBalance) < 0 {
+		return nil, fmt.Errorf("account %s has balance %s, need at least %s ETH", addr.Hex(), utils.FormatEther(balance), utils.FormatEther(minBalance))
+	}
+
 	return &RpcContext{
-		Conf:   conf,
-		EthCli: ethCli,
+		Conf:     conf,
+		EthCli:   ethCli,
+		EthCliWs: ethCliWs,
+		ChainId:  chainId,
 		Acc: &types.Account{
-			Address: crypto.PubkeyToAddress(ecdsaPrivKey.PublicKey),
+			Address: addr,
 			PrivKey: ecdsaPrivKey,
 		},
+		TestedRPCs:          make(map[types.RpcName]*types.RpcResult),
+		TxConfirmationTimes: make(map[string][]time.Duration),
+		BlockCache:          lru.NewCache[uint64, *gethtypes.Block](10),
 	}, nil
 }
 
 func (rCtx *RpcContext) AlreadyTested(rpc types.RpcName) *types.RpcResult {
-	for _, testedRPC := range rCtx.AlreadyTestedRPCs {
-		if rpc == testedRPC.Method {
-			return testedRPC
-		}
+	return rCtx.TestedRPCs[rpc]
+}
+
+// RecordCustomResult records a result with Warning status if there are warnings, otherwise Ok status
+func (rCtx *RpcContext) RecordCustomResult(name types.RpcName, value interface{}, warnings []string) *types.RpcResult {
+	status := types.Ok
+	if len(warnings) > 0 {
+		status = types.Warning
+	}
+
+	result := &types.RpcResult{
+		Method:   name,
+		Status:   status,
+		Value:    value,
+		Warnings: warnings,
+	}
+	rCtx.RecordResult(result)
+
+	return result
+}
+
+// RecordResult records the result of a method unless it is already recorded, so the first result wins
+// and sub-results recorded as a side effect of other checks never overwrite it
+func (rCtx *RpcContext) RecordResult(result *types.RpcResult) {
+	if _, ok := rCtx.TestedRPCs[result.Method]; !ok {
+		rCtx.TestedRPCs[result.Method] = result
+	}
+}
+
+// TxConfirmationTime returns the total time spent waiting for transactions to be mined
+func (rCtx *RpcContext) TxConfirmationTime() time.Duration {
+	return rCtx.TxWaitTime
+}
+
+// BlockByNumber returns the block of the given number from the cache, fetching it if not cached
+func (rCtx *RpcContext) BlockByNumber(ctx context.Context, num uint64) (*gethtypes.Block, error) {
+	if blk, ok := rCtx.BlockCache.Get(num); ok {
+		return blk, nil
+	}
+	blk, err := rCtx.EthCli.BlockByNumber(ctx, new(big.Int).SetUint64(num))
+	if err != nil {
+		return nil, err
+	}
+	rCtx.BlockCache.Add(num, blk)
+	return blk, nil
+}
+
+// TempAccount returns an account used as a temporary recipient.
+// It is derived from the seed if set, otherwise it is random.
+func (rCtx *RpcContext) TempAccount() types.Account {
+	if rCtx.Seed != "" {
+		return utils.AccountFromSeed([]byte(rCtx.Seed))
+	}
+	return utils.MustCreateRandomAccount()
+}
+
+func RpcEthSyncing(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(EthSyncing); result != nil {
+		return result, nil
 	}
-	return nil
 
+	// progress is nil if the node is fully synced
+	progress, err := rCtx.EthCli.SyncProgress(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if progress == nil {
+		return rCtx.RecordCustomResult(EthSyncing, false, nil), nil
+	}
+
+	warnings := []string{fmt.Sprintf("node is syncing (current block: %d, highest block: %d), results may be incomplete",
+		progress.CurrentBlock, progress.HighestBlock)}
+	return rCtx.RecordCustomResult(EthSyncing, progress, warnings), nil
 }
 
-func RpcGetBlockNumber(rCtx *RpcContext) (*types.RpcResult, error) {
+func RpcGetBlockNumber(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
 	if result := rCtx.AlreadyTested(GetBlockNumber); result != nil {
 		return result, nil
 	}
-	blockNumber, err := rCtx.EthCli.BlockNumber(context.Background())
+	blockNumber, err := rCtx.EthCli.BlockNumber(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -124,28 +310,15 @@ func RpcGetBlockNumber(rCtx *RpcContext) (*types.RpcResult, error) {
 		warnings = append(warnings, "blockNumber is zero")
 	}
 
-	status := types.Ok
-	if len(warnings) > 0 {
-		status = types.Warning
-	}
-
-	result := &types.RpcResult{
-		Method:   GetBlockNumber,
-		Status:   status,
-		Value:    blockNumber,
-		Warnings: warnings,
-	}
-	rCtx.AlreadyTestedRPCs = append(rCtx.AlreadyTestedRPCs, result)
-
-	return result, nil
+	return rCtx.RecordCustomResult(GetBlockNumber, blockNumber, warnings), nil
 }
 
-func RpcGetGasPrice(rCtx *RpcContext) (*types.RpcResult, error) {
+func RpcGetGasPrice(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
 	if result := rCtx.AlreadyTested(GetGasPrice); result != nil {
 		return result, nil
 	}
 
-	gasPrice, err := rCtx.EthCli.SuggestGasPrice(context.Background())
+	gasPrice, err := rCtx.EthCli.SuggestGasPrice(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -155,28 +328,15 @@ func RpcGetGasPrice(rCtx *RpcContext) (*types.RpcResult, error) {
 		warnings = append(warnings, "gasPrice is nil or zero")
 	}
 
-	status := types.Ok
-	if len(warnings) > 0 {
-		status = types.Warning
-	}
-
-	result := &types.RpcResult{
-		Method:   GetGasPrice,
-		Status:   status,
-		Value:    gasPrice.String(),
-		Warnings: warnings,
-	}
-	rCtx.AlreadyTestedRPCs = append(rCtx.AlreadyTestedRPCs, result)
-
-	return result, nil
+	return rCtx.RecordCustomResult(GetGasPrice, gasPrice.String(), warnings), nil
 }
 
-func RpcGetMaxPriorityFeePerGas(rCtx *RpcContext) (*types.RpcResult, error) {
+func RpcGetMaxPriorityFeePerGas(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
 	if result := rCtx.AlreadyTested(GetMaxPriorityFeePerGas); result != nil {
 		return result, nil
 	}
 
-	maxPriorityFeePerGas, err := rCtx.EthCli.SuggestGasTipCap(context.Background())
+	maxPriorityFeePerGas, err := rCtx.EthCli.SuggestGasTipCap(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -186,538 +346,2439 @@ func RpcGetMaxPriorityFeePerGas(rCtx *RpcContext) (*types.RpcResult, error) {
 		warnings = append(warnings, "maxPriorityFeePerGas is nil or zero")
 	}
 
-	status := types.Ok
-	if len(warnings) > 0 {
-		status = types.Warning
-	}
-
-	result := &types.RpcResult{
-		Method:   GetMaxPriorityFeePerGas,
-		Status:   status,
-		Value:    maxPriorityFeePerGas.String(),
-		Warnings: warnings,
-	}
-	rCtx.AlreadyTestedRPCs = append(rCtx.AlreadyTestedRPCs, result)
-
-	return result, nil
+	return rCtx.RecordCustomResult(GetMaxPriorityFeePerGas, maxPriorityFeePerGas.String(), warnings), nil
 }
 
-func RpcGetChainId(rCtx *RpcContext) (*types.RpcResult, error) {
+func RpcGetChainId(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
 	if result := rCtx.AlreadyTested(GetChainId); result != nil {
 		return result, nil
 	}
 
-	chainId, err := rCtx.EthCli.ChainID(context.Background())
+	chainId, err := rCtx.EthCli.ChainID(ctx)
 	if err != nil {
 		return nil, err
 	}
 
+	if result := chainIdResult(rCtx, chainId); result.Status == types.Error {
+		rCtx.RecordResult(result)
+		return result, nil
+	}
+
 	var warnings []string
 	if chainId.Cmp(big.NewInt(0)) == 0 {
 		warnings = append(warnings, "chainId is nil")
 	}
 
-	status := types.Ok
-	if len(warnings) > 0 {
-		status = types.Warning
-	}
+	return rCtx.RecordCustomResult(GetChainId, chainId.String(), warnings), nil
+}
 
+// chainIdResult returns the eth_chainId result, which is an error if the chain ID differs from expected_chain_id
+func chainIdResult(rCtx *RpcContext, chainId *big.Int) *types.RpcResult {
 	result := &types.RpcResult{
-		Method:   GetChainId,
-		Status:   status,
-		Value:    chainId.String(),
-		Warnings: warnings,
+		Method: GetChainId,
+		Status: types.Ok,
+		Value:  chainId.String(),
 	}
-	rCtx.AlreadyTestedRPCs = append(rCtx.AlreadyTestedRPCs, result)
-
-	return result, nil
+	if expected := rCtx.Conf.ExpectedChainId; expected != 0 && chainId.Cmp(big.NewInt(expected)) != 0 {
+		result.Status = types.Error
+		result.ErrMsg = fmt.Sprintf("chainId %s does not match expected_chain_id %d", chainId, expected)
+	}
+	return result
 }
 
-func RpcGetBalance(rCtx *RpcContext) (*types.RpcResult, error) {
-	if result := rCtx.AlreadyTested(GetBalance); result != nil {
+func RpcNetVersion(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(NetVersion); result != nil {
 		return result, nil
 	}
 
-	balance, err := rCtx.EthCli.BalanceAt(context.Background(), rCtx.Acc.Address, nil)
-	if err != nil {
+	var version string
+	if err := rCtx.EthCli.Client().CallContext(ctx, &version, string(NetVersion)); err != nil {
 		return nil, err
 	}
-
-	var warnings []string
-	if balance.Cmp(big.NewInt(0)) == 0 {
-		warnings = append(warnings, "balance is zero")
+	networkId, ok := new(big.Int).SetString(version, 10)
+	if !ok {
+		return nil, fmt.Errorf("net_version %s is not a decimal string", version)
 	}
 
-	status := types.Ok
-	if len(warnings) > 0 {
-		status = types.Warning
+	if rCtx.ChainId == nil {
+		chainId, err := rCtx.EthCli.ChainID(ctx)
+		if err != nil {
+			return nil, err
+		}
+		rCtx.ChainId = chainId
 	}
 
-	result := &types.RpcResult{
-		Method:   GetBalance,
-		Status:   status,
-		Value:    balance.String(),
-		Warnings: warnings,
+	// some networks intentionally split the network id and the chain id
+	var warnings []string
+	if networkId.Cmp(rCtx.ChainId) != 0 {
+		warnings = append(warnings, fmt.Sprintf("net_version %s differs from eth_chainId %s", networkId, rCtx.ChainId))
 	}
-	rCtx.AlreadyTestedRPCs = append(rCtx.AlreadyTestedRPCs, result)
 
-	return result, nil
+	return rCtx.RecordCustomResult(NetVersion, version, warnings), nil
 }
 
-func RpcGetTransactionCount(rCtx *RpcContext) (*types.RpcResult, error) {
-	if result := rCtx.AlreadyTested(GetTransactionCount); result != nil {
+var gethVersionRegexp = regexp.MustCompile(`Geth/v(\d+)\.(\d+)\.(\d+)`)
+
+func RpcWeb3ClientVersion(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(Web3ClientVersion); result != nil {
 		return result, nil
 	}
 
-	nonce, err := rCtx.EthCli.PendingNonceAt(context.Background(), rCtx.Acc.Address)
-	if err != nil {
+	var clientVersion string
+	if err := rCtx.EthCli.Client().CallContext(ctx, &clientVersion, string(Web3ClientVersion)); err != nil {
 		return nil, err
 	}
 
+	// warn if the node is older geth than the reference client used to write the tests
 	var warnings []string
-	if nonce == 0 {
-		warnings = append(warnings, "nonce is zero")
-	}
-
-	status := types.Ok
-	if len(warnings) > 0 {
-		status = types.Warning
+	if m := gethVersionRegexp.FindStringSubmatch(clientVersion); m != nil {
+		if compareVersions(m[1:], strings.Split(GethVersion, ".")) < 0 {
+			warnings = append(warnings, fmt.Sprintf("remote node is older than the reference client Geth v%s", GethVersion))
+		}
 	}
 
-	return &types.RpcResult{
-		Method:   GetTransactionCount,
-		Status:   status,
-		Value:    nonce,
-		Warnings: warnings,
-	}, nil
+	return rCtx.RecordCustomResult(Web3ClientVersion, clientVersion, warnings), nil
 }
 
-func RpcGetBlockByHash(rCtx *RpcContext) (*types.RpcResult, error) {
-	if result := rCtx.AlreadyTested(GetBlockByHash); result != nil {
+func RpcWeb3Sha3(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(Web3Sha3); result != nil {
 		return result, nil
 	}
 
-	blkNum, err := rCtx.EthCli.BlockNumber(context.Background())
-	if err != nil {
+	// keccak256("hello world")
+	input := "0x68656c6c6f20776f726c64"
+	expected := "0x47173285a8d7341e5e972fc677286384f802f8ef42a5ec5f03bbfa254cb01fad"
+	var hash string
+	if err := rCtx.EthCli.Client().CallContext(ctx, &hash, string(Web3Sha3), input); err != nil {
 		return nil, err
 	}
+	if hash != expected {
+		return nil, fmt.Errorf("web3_sha3 of %s returns %s, expected %s", input, hash, expected)
+	}
 
-	blk, err := rCtx.EthCli.BlockByNumber(context.Background(), new(big.Int).SetUint64(blkNum))
-	if err != nil {
-		return nil, err
+	return rCtx.RecordCustomResult(Web3Sha3, hash, nil), nil
+}
+
+// compareVersions compares dot separated numeric version parts, returning -1, 0 or 1
+func compareVersions(a, b []string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		x, _ := strconv.Atoi(a[i])
+		y, _ := strconv.Atoi(b[i])
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
 	}
+	return 0
+}
 
-	block, err := rCtx.EthCli.BlockByHash(context.Background(), blk.Hash())
-	if err != nil {
-		return nil, err
+func RpcGetBlobBaseFee(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(GetBlobBaseFee); result != nil {
+		return result, nil
 	}
 
-	if !cmp.Equal(blk, block) {
-		return nil, errors.New("implementation error: blockByNumber and blockByHash return different blocks")
+	var blobBaseFee hexutil.Big
+	if err := rCtx.EthCli.Client().CallContext(ctx, &blobBaseFee, string(GetBlobBaseFee)); err != nil {
+		var rpcErr rpc.Error
+		if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == errCodeMethodNotFound {
+			// method not found, the chain is pre-Cancun
+			return rCtx.RecordCustomResult(GetBlobBaseFee, nil, []string{fmt.Sprintf("eth_blobBaseFee not supported, chain may pre-date EIP-4844: %v", err)}), nil
+		}
+		return nil, err
+	}
+	// blob base fee is at least the minimum blob gas price
+	if blobBaseFee.ToInt().Cmp(big.NewInt(params.BlobTxMinBlobGasprice)) < 0 {
+		return nil, fmt.Errorf("blob base fee %s is less than the minimum blob gas price %d", blobBaseFee.ToInt(), params.BlobTxMinBlobGasprice)
 	}
 
-	result := &types.RpcResult{
-		Method: GetBlockByHash,
-		Status: types.Ok,
-		Value:  utils.MustBeautifyBlock(types.NewRpcBlock(block)),
+	// the last baseFeePerBlobGas of eth_feeHistory is the blob base fee of the next block
+	var warnings []string
+	var feeHistory struct {
+		BaseFeePerBlobGas []*hexutil.Big `json:"baseFeePerBlobGas"`
+	}
+	err := rCtx.EthCli.Client().CallContext(ctx, &feeHistory, string(GetFeeHistory), hexutil.Uint64(1), "latest", []float64{})
+	if err == nil && len(feeHistory.BaseFeePerBlobGas) > 0 {
+		next := feeHistory.BaseFeePerBlobGas[len(feeHistory.BaseFeePerBlobGas)-1].ToInt()
+		if next.Cmp(blobBaseFee.ToInt()) != 0 {
+			warnings = append(warnings, fmt.Sprintf("blob base fee %s differs from baseFeePerBlobGas of eth_feeHistory %s, a new block may be mined",
+				blobBaseFee.ToInt(), next))
+		}
 	}
-	rCtx.AlreadyTestedRPCs = append(rCtx.AlreadyTestedRPCs, result)
 
-	return result, nil
+	return rCtx.RecordCustomResult(GetBlobBaseFee, blobBaseFee.ToInt().String(), warnings), nil
 }
 
-func RpcGetBlockByNumber(rCtx *RpcContext) (*types.RpcResult, error) {
-	if result := rCtx.AlreadyTested(GetBlockByNumber); result != nil {
+func RpcGetFeeHistory(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(GetFeeHistory); result != nil {
 		return result, nil
 	}
 
-	blkNum, err := rCtx.EthCli.BlockNumber(context.Background())
+	percentiles := []float64{25, 50, 75}
+	feeHistory, err := rCtx.EthCli.FeeHistory(ctx, 10, nil, percentiles)
 	if err != nil {
 		return nil, err
 	}
 
-	blk, err := rCtx.EthCli.BlockByNumber(context.Background(), new(big.Int).SetUint64(blkNum))
-	if err != nil {
-		return nil, err
+	// baseFeePerGas includes the projected base fee of the next block
+	if len(feeHistory.BaseFee) != len(feeHistory.Reward)+1 {
+		return nil, fmt.Errorf("baseFeePerGas has %d entries, expected one more than reward (%d entries)",
+			len(feeHistory.BaseFee), len(feeHistory.Reward))
 	}
 
-	result := &types.RpcResult{
-		Method: GetBlockByNumber,
-		Status: types.Ok,
-		Value:  utils.MustBeautifyBlock(types.NewRpcBlock(blk)),
+	// rewards of a block must be sorted by percentile
+	for i, rewards := range feeHistory.Reward {
+		for j := 1; j < len(rewards); j++ {
+			if rewards[j-1].Cmp(rewards[j]) > 0 {
+				return nil, fmt.Errorf("reward of %vth percentile (%s) is greater than %vth percentile (%s) at block %d",
+					percentiles[j-1], rewards[j-1], percentiles[j], rewards[j], new(big.Int).Add(feeHistory.OldestBlock, big.NewInt(int64(i))))
+			}
+		}
 	}
-	rCtx.AlreadyTestedRPCs = append(rCtx.AlreadyTestedRPCs, result)
 
-	return result, nil
+	// baseFeePerGas must match baseFee of the corresponding blocks
+	for i := range feeHistory.Reward {
+		num := new(big.Int).Add(feeHistory.OldestBlock, big.NewInt(int64(i)))
+		blk, err := rCtx.BlockByNumber(ctx, num.Uint64())
+		if err != nil {
+			return nil, err
+		}
+		if blk.BaseFee() == nil || blk.BaseFee().Cmp(feeHistory.BaseFee[i]) != 0 {
+			return nil, fmt.Errorf("baseFeePerGas of eth_feeHistory (%s) differs from baseFee of block %s (%v)", feeHistory.BaseFee[i], num, blk.BaseFee())
+		}
+	}
+
+	var warnings []string
+	allZero := len(feeHistory.Reward) > 0
+	for _, rewards := range feeHistory.Reward {
+		for _, reward := range rewards {
+			if reward.Sign() != 0 {
+				allZero = false
+			}
+		}
+	}
+	if allZero {
+		warnings = append(warnings, "all rewards are zero, which is suspicious for a live chain")
+	}
+
+	// base fee increases by 12.5% after a full block, 1% tolerance is allowed
+	for i, ratio := range feeHistory.GasUsedRatio {
+		if ratio < 1 || i+1 >= len(feeHistory.BaseFee) {
+			continue
+		}
+		expected := new(big.Float).Mul(new(big.Float).SetInt(feeHistory.BaseFee[i]), big.NewFloat(1.125))
+		diff := new(big.Float).Sub(new(big.Float).SetInt(feeHistory.BaseFee[i+1]), expected)
+		if diff.Abs(diff).Cmp(new(big.Float).Mul(expected, big.NewFloat(0.01))) > 0 {
+			warnings = append(warnings, fmt.Sprintf("base fee after full block %d is %s, expected about %s",
+				new(big.Int).Add(feeHistory.OldestBlock, big.NewInt(int64(i))), feeHistory.BaseFee[i+1], expected.Text('f', 0)))
+		}
+	}
+
+	return rCtx.RecordCustomResult(GetFeeHistory, feeHistory, warnings), nil
 }
 
-func RpcSendRawTransactionTransferValue(rCtx *RpcContext) (*types.RpcResult, error) {
-	// testedRPCs is a slice of RpcResult that will be appended to rCtx.AlreadyTestedRPCs
-	// if the transaction is successfully sent
-	var testedRPCs []*types.RpcResult
-	var err error
-	// Create a new transaction
-	if rCtx.ChainId, err = rCtx.EthCli.ChainID(context.Background()); err != nil {
-		return nil, err
+func RpcGetBalance(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(GetBalance); result != nil {
+		return result, nil
 	}
-	testedRPCs = append(testedRPCs, &types.RpcResult{
-		Method: GetChainId,
-		Status: types.Ok,
-		Value:  rCtx.ChainId.String(),
-	})
 
-	nonce, err := rCtx.EthCli.PendingNonceAt(context.Background(), rCtx.Acc.Address)
+	balance, err := rCtx.EthCli.BalanceAt(ctx, rCtx.Acc.Address, nil)
 	if err != nil {
 		return nil, err
 	}
-	testedRPCs = append(testedRPCs, &types.RpcResult{
-		Method: GetTransactionCount,
-		Status: types.Ok,
-		Value:  nonce,
-	})
-
-	if rCtx.MaxPriorityFeePerGas, err = rCtx.EthCli.SuggestGasTipCap(context.Background()); err != nil {
-		return nil, err
+	// the balance may have been spent since it was checked when connecting
+	if minBalance := rCtx.Conf.MinBalanceInWei(); balance.Cmp(minBalance) < 0 {
+		return nil, fmt.Errorf("%w: balance of %s is %s wei, need at least %s wei",
+			ErrInsufficientBalance, rCtx.Acc.Address.Hex(), balance, minBalance)
 	}
-	testedRPCs = append(testedRPCs, &types.RpcResult{
-		Method: GetMaxPriorityFeePerGas,
-		Status: types.Ok,
-		Value:  rCtx.MaxPriorityFeePerGas.String(),
-	})
-	if rCtx.GasPrice, err = rCtx.EthCli.SuggestGasPrice(context.Background()); err != nil {
-		return nil, err
+
+	var warnings []string
+	if balance.Cmp(big.NewInt(0)) == 0 {
+		warnings = append(warnings, "balance is zero")
 	}
-	testedRPCs = append(testedRPCs, &types.RpcResult{
-		Method: GetGasPrice,
-		Status: types.Ok,
-		Value:  rCtx.GasPrice.String(),
-	})
 
-	randomRecipient := utils.MustCreateRandomAccount().Address
-	value := new(big.Int).SetUint64(1)
-	balanceBeforeSend, err := rCtx.EthCli.BalanceAt(context.Background(), rCtx.Acc.Address, nil)
-	if err != nil {
-		return nil, err
+	return rCtx.RecordCustomResult(GetBalance, balance.String(), warnings), nil
+}
+
+// spentByRichAccount returns the gas fees and value of the processed transactions sent by the rich account
+func spentByRichAccount(ctx context.Context, rCtx *RpcContext) (*big.Int, error) {
+	signer := gethtypes.LatestSignerForChainID(rCtx.ChainId)
+	spent := new(big.Int)
+	for _, hash := range rCtx.ProcessedTransactions {
+		tx, _, err := rCtx.EthCli.TransactionByHash(ctx, hash)
+		if err != nil {
+			return nil, err
+		}
+		if from, err := gethtypes.Sender(signer, tx); err != nil || from != rCtx.Acc.Address {
+			continue
+		}
+		receipt, err := rCtx.EthCli.TransactionReceipt(ctx, hash)
+		if err != nil {
+			return nil, err
+		}
+		fee := new(big.Int).Mul(new(big.Int).SetUint64(receipt.GasUsed), receipt.EffectiveGasPrice)
+		spent.Add(spent, fee.Add(fee, tx.Value()))
 	}
-	testedRPCs = append(testedRPCs, &types.RpcResult{
-		Method: GetBalance,
-		Status: types.Ok,
-		Value:  balanceBeforeSend.String(),
-	})
+	return spent, nil
+}
 
-	if balanceBeforeSend.Cmp(value) < 0 {
-		return nil, errors.New("insufficient balanceBeforeSend")
+func RpcGetBalanceSpecialTags(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(GetBalanceSpecialTags); result != nil {
+		return result, nil
 	}
 
-	tx := gethtypes.NewTx(&gethtypes.DynamicFeeTx{
-		ChainID:   rCtx.ChainId,
-		Nonce:     nonce,
-		GasTipCap: rCtx.MaxPriorityFeePerGas,
-		GasFeeCap: new(big.Int).Add(rCtx.GasPrice, big.NewInt(1000000000)),
-		Gas:       21000, // fixed gas limit for transfer
-		To:        &randomRecipient,
-		Value:     value,
-	})
+	var warnings []string
+	balances := make(map[string]*big.Int)
+	for _, tag := range []string{"safe", "finalized"} {
+		var balance hexutil.Big
+		if err := rCtx.EthCli.Client().CallContext(ctx, &balance, string(GetBalance), rCtx.Acc.Address, tag); err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s block tag is not supported: %v", tag, err))
+			continue
+		}
+		balances[tag] = balance.ToInt()
+	}
 
-	// TODO: Make signer using types.MakeSigner with chain params
-	signer := gethtypes.NewLondonSigner(rCtx.ChainId)
-	signedTx, err := gethtypes.SignTx(tx, signer, rCtx.Acc.PrivKey)
-	if err != nil {
-		return nil, err
+	// the balance at finalized can exceed the balance at safe by at most what the rich account spent in between,
+	// which is bounded by what it spent in this run (approximately, as the key may be used outside this run)
+	safe, finalized := balances["safe"], balances["finalized"]
+	if safe != nil && finalized != nil {
+		spent, err := spentByRichAccount(ctx, rCtx)
+		if err != nil {
+			return nil, err
+		}
+		if bound := new(big.Int).Add(safe, spent); finalized.Cmp(bound) > 0 {
+			warnings = append(warnings, fmt.Sprintf("balance at finalized %s is greater than balance at safe %s + gas consumed %s", finalized, safe, spent))
+		}
 	}
 
-	if err = rCtx.EthCli.SendTransaction(context.Background(), signedTx); err != nil {
-		return nil, err
+	value := make(map[string]string)
+	for tag, balance := range balances {
+		value[tag] = balance.String()
 	}
-	result := &types.RpcResult{
-		Method: SendRawTransaction,
-		Status: types.Ok,
-		Value:  signedTx.Hash().Hex(),
+	return rCtx.RecordCustomResult(GetBalanceSpecialTags, value, warnings), nil
+}
+
+func RpcGetFinalizedState(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(GetFinalizedState); result != nil {
+		return result, nil
 	}
-	testedRPCs = append(testedRPCs, result)
 
-	// wait for the transaction to be mined
-	tout, _ := time.ParseDuration(rCtx.Conf.Timeout)
-	if err = WaitForTx(rCtx, signedTx.Hash(), tout); err != nil {
+	latestBalance, err := rCtx.EthCli.BalanceAt(ctx, rCtx.Acc.Address, nil)
+	if err != nil {
 		return nil, err
 	}
-
-	balance, err := rCtx.EthCli.BalanceAt(context.Background(), rCtx.Acc.Address, nil)
+	latestNonce, err := rCtx.EthCli.NonceAt(ctx, rCtx.Acc.Address, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var finalizedBalance hexutil.Big
+	if err = rCtx.EthCli.Client().CallContext(ctx, &finalizedBalance, string(GetBalance), rCtx.Acc.Address, "finalized"); err != nil {
+		return rCtx.RecordCustomResult(GetFinalizedState, nil, []string{fmt.Sprintf("finalized block tag is not supported: %v", err)}), nil
+	}
+	var finalizedNonce hexutil.Uint64
+	if err = rCtx.EthCli.Client().CallContext(ctx, &finalizedNonce, string(GetTransactionCount), rCtx.Acc.Address, "finalized"); err != nil {
+		return rCtx.RecordCustomResult(GetFinalizedState, nil, []string{fmt.Sprintf("finalized block tag is not supported: %v", err)}), nil
+	}
+
+	// the finalized state lags behind the latest state, so the nonce can't be greater at finalized
+	if uint64(finalizedNonce) > latestNonce {
+		return nil, fmt.Errorf("nonce at finalized %d is greater than nonce at latest %d", uint64(finalizedNonce), latestNonce)
+	}
+	// the rich account spends between finalized and latest, so the balance at finalized can exceed the balance
+	// at latest by at most what it spent in this run, as eth_getBalance (safe/finalized) bounds it
+	spent, err := spentByRichAccount(ctx, rCtx)
+	if err != nil {
+		return nil, err
+	}
+	if bound := new(big.Int).Add(latestBalance, spent); finalizedBalance.ToInt().Cmp(bound) > 0 {
+		return nil, fmt.Errorf("balance at finalized %s is greater than balance at latest %s + gas consumed %s", finalizedBalance.ToInt(), latestBalance, spent)
+	}
+
+	value := map[string]string{
+		"balance": finalizedBalance.ToInt().String(),
+		"nonce":   strconv.FormatUint(uint64(finalizedNonce), 10),
+	}
+	return rCtx.RecordCustomResult(GetFinalizedState, value, nil), nil
+}
+
+func RpcGetBalanceOpcode(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(GetBalanceOpcode); result != nil {
+		return result, nil
+	}
+
+	checkerAbi, err := abi.JSON(strings.NewReader(contracts.BalanceCheckerAbi))
+	if err != nil {
+		log.Fatalf("Failed to parse BalanceChecker ABI: %v", err)
+	}
+	checkerAddr, err := deployContract(ctx, rCtx, common.FromHex(string(contracts.BalanceCheckerByteCode)))
+	if err != nil {
+		return nil, err
+	}
+
+	// compare the balance read by the BALANCE opcode with eth_getBalance at the same block
+	blkNum, err := rCtx.EthCli.BlockNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+	blk := new(big.Int).SetUint64(blkNum)
+	data, err := checkerAbi.Pack("getBalance", rCtx.Acc.Address)
+	if err != nil {
+		log.Fatalf("Failed to pack transaction data: %v", err)
+	}
+	res, err := rCtx.EthCli.CallContract(ctx, ethereum.CallMsg{
+		To:   &checkerAddr,
+		Data: data,
+	}, blk)
+	if err != nil {
+		return nil, err
+	}
+	balanceByOpcode := new(big.Int).SetBytes(res)
+
+	balance, err := rCtx.EthCli.BalanceAt(ctx, rCtx.Acc.Address, blk)
+	if err != nil {
+		return nil, err
+	}
+	if balanceByOpcode.Cmp(balance) != 0 {
+		return nil, fmt.Errorf("balance by BALANCE opcode (%s) differs from eth_getBalance (%s) at block %d",
+			balanceByOpcode, balance, blkNum)
+	}
+
+	return rCtx.RecordCustomResult(GetBalanceOpcode, balance.String(), nil), nil
+}
+
+func RpcGetBalanceSelfBalance(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(GetBalanceSelfBalance); result != nil {
+		return result, nil
+	}
+
+	selfBalanceAbi, err := abi.JSON(strings.NewReader(contracts.SelfBalanceAbi))
+	if err != nil {
+		log.Fatalf("Failed to parse SelfBalance ABI: %v", err)
+	}
+	contractAddr, err := deployContract(ctx, rCtx, common.FromHex(string(contracts.SelfBalanceByteCode)))
+	if err != nil {
+		return nil, err
+	}
+
+	// send 1 wei to the receive function of the contract
+	value := big.NewInt(1)
+	signedTx, err := signAndSendTx(ctx, rCtx, &contractAddr, nil, value, 100000)
+	if err != nil {
+		return nil, err
+	}
+	tout, _ := time.ParseDuration(rCtx.Conf.Timeout)
+	if err = WaitForTx(ctx, rCtx, signedTx.Hash(), tout); err != nil {
+		return nil, err
+	}
+	receipt, err := rCtx.EthCli.TransactionReceipt(ctx, signedTx.Hash())
+	if err != nil {
+		return nil, err
+	}
+	if len(receipt.Logs) != 1 || new(big.Int).SetBytes(receipt.Logs[0].Data).Cmp(value) != 0 {
+		return nil, fmt.Errorf("receive function of the contract must emit the balance %s", value)
+	}
+
+	// SELFBALANCE via eth_call and eth_getBalance must both return the received value
+	data, err := selfBalanceAbi.Pack("getBalance")
+	if err != nil {
+		log.Fatalf("Failed to pack transaction data: %v", err)
+	}
+	res, err := rCtx.EthCli.CallContract(ctx, ethereum.CallMsg{
+		To:   &contractAddr,
+		Data: data,
+	}, receipt.BlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if selfBalance := new(big.Int).SetBytes(res); selfBalance.Cmp(value) != 0 {
+		return nil, fmt.Errorf("balance by SELFBALANCE opcode is %s, expected %s", selfBalance, value)
+	}
+	balance, err := rCtx.EthCli.BalanceAt(ctx, contractAddr, receipt.BlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if balance.Cmp(value) != 0 {
+		return nil, fmt.Errorf("eth_getBalance of the contract is %s, expected %s", balance, value)
+	}
+
+	return rCtx.RecordCustomResult(GetBalanceSelfBalance, balance.String(), nil), nil
+}
+
+// deployContract deploys the contract bytecode from the rich account and returns the contract address
+func deployContract(ctx context.Context, rCtx *RpcContext, bytecode []byte) (common.Address, error) {
+	signedTx, err := signAndSendTx(ctx, rCtx, nil, bytecode, nil, 1000000)
+	if err != nil {
+		return common.Address{}, err
+	}
+	tout, _ := time.ParseDuration(rCtx.Conf.Timeout)
+	if err = WaitForTx(ctx, rCtx, signedTx.Hash(), tout); err != nil {
+		return common.Address{}, err
+	}
+	receipt, err := rCtx.EthCli.TransactionReceipt(ctx, signedTx.Hash())
+	if err != nil {
+		return common.Address{}, err
+	}
+	return receipt.ContractAddress, nil
+}
+
+func RpcGetBalanceZeroAddress(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(GetBalanceZeroAddress); result != nil {
+		return result, nil
+	}
+
+	balance, err := rCtx.EthCli.BalanceAt(ctx, common.Address{}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// most chains have non-zero balance at the zero address because of burns
+	var warnings []string
+	if balance.Cmp(big.NewInt(0)) == 0 {
+		warnings = append(warnings, "balance of zero address is zero, node may return a default value without checking state")
+	}
+
+	return rCtx.RecordCustomResult(GetBalanceZeroAddress, balance.String(), warnings), nil
+}
+
+func RpcGetBalanceBatch(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(GetBalanceBatch); result != nil {
+		return result, nil
+	}
+
+	// sequential query right before the batch, the recorded eth_getBalance result may be taken before sending transactions
+	balance, err := rCtx.EthCli.BalanceAt(ctx, rCtx.Acc.Address, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	addrs := []common.Address{rCtx.Acc.Address, rCtx.ERC20Addr, {}}
+	balances := make([]hexutil.Big, len(addrs))
+	batch := make([]rpc.BatchElem, len(addrs))
+	for i, addr := range addrs {
+		batch[i] = rpc.BatchElem{
+			Method: string(GetBalance),
+			Args:   []interface{}{addr, "latest"},
+			Result: &balances[i],
+		}
+	}
+	if err = rCtx.EthCli.Client().BatchCallContext(ctx, batch); err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string)
+	for i, elem := range batch {
+		if elem.Error != nil {
+			return nil, fmt.Errorf("batch eth_getBalance of %s failed: %v", addrs[i].Hex(), elem.Error)
+		}
+		values[addrs[i].Hex()] = balances[i].String()
+	}
+
+	if balances[0].ToInt().Cmp(balance) != 0 {
+		return nil, fmt.Errorf("balance from batch request (%s) differs from sequential request (%s)", balances[0].ToInt(), balance)
+	}
+
+	return rCtx.RecordCustomResult(GetBalanceBatch, values, nil), nil
+}
+
+func RpcGetBalanceContract(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(GetBalanceContract); result != nil {
+		return result, nil
+	}
+
+	if rCtx.ERC20Addr == (common.Address{}) {
+		return nil, errors.New("no contract address, must be deployed first")
+	}
+
+	balance, err := rCtx.EthCli.BalanceAt(ctx, rCtx.ERC20Addr, nil)
+	if err != nil {
+		return rCtx.RecordCustomResult(GetBalanceContract, err.Error(), []string{"node returns an error for balance of a contract address"}), nil
+	}
+
+	value := balance.String()
+	if balance.Sign() > 0 {
+		value = fmt.Sprintf("%s (contract received ETH unexpectedly)", balance)
+	}
+
+	return rCtx.RecordCustomResult(GetBalanceContract, value, nil), nil
+}
+
+func RpcGetTransactionCount(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(GetTransactionCount); result != nil {
+		return result, nil
+	}
+
+	nonce, err := rCtx.EthCli.PendingNonceAt(ctx, rCtx.Acc.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	var warnings []string
+	if nonce == 0 {
+		warnings = append(warnings, "nonce is zero")
+	}
+
+	return rCtx.RecordCustomResult(GetTransactionCount, nonce, warnings), nil
+}
+
+func RpcGetBlockByHash(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(GetBlockByHash); result != nil {
+		return result, nil
+	}
+
+	blkNum, err := rCtx.EthCli.BlockNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	blk, err := rCtx.BlockByNumber(ctx, blkNum)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := rCtx.EthCli.BlockByHash(ctx, blk.Hash())
+	if err != nil {
+		return nil, err
+	}
+
+	if diff := utils.DiffBlocks(blk, block); diff != "" {
+		return nil, fmt.Errorf("implementation error: blockByNumber and blockByHash return different blocks: %s", utils.TruncateString(diff, 500))
+	}
+
+	// check the non-hydrated block with a block including our transactions if the latest block is empty
+	checkBlock := block
+	if len(block.Transactions()) == 0 && len(rCtx.BlockNumsIncludingTx) > 0 {
+		if checkBlock, err = rCtx.BlockByNumber(ctx, rCtx.BlockNumsIncludingTx[0]); err != nil {
+			return nil, err
+		}
+	}
+	if err = verifyNonHydratedBlock(ctx, rCtx, checkBlock); err != nil {
+		return nil, err
+	}
+
+	return rCtx.RecordCustomResult(GetBlockByHash, utils.MustBeautifyBlock(types.NewRpcBlock(block)), nil), nil
+}
+
+// verifyNonHydratedBlock fetches the block with transaction hashes only and compares them with the hydrated block
+func verifyNonHydratedBlock(ctx context.Context, rCtx *RpcContext, blk *gethtypes.Block) error {
+	var nonHydrated struct {
+		Transactions []common.Hash `json:"transactions"`
+	}
+	if err := rCtx.EthCli.Client().CallContext(ctx, &nonHydrated, string(GetBlockByHash), blk.Hash(), false); err != nil {
+		return err
+	}
+
+	if len(nonHydrated.Transactions) != len(blk.Transactions()) {
+		return fmt.Errorf("non-hydrated block has %d transaction hashes, but hydrated block has %d transactions",
+			len(nonHydrated.Transactions), len(blk.Transactions()))
+	}
+	if len(nonHydrated.Transactions) == 0 {
+		return nil
+	}
+
+	tx, err := rCtx.EthCli.TransactionInBlock(ctx, blk.Hash(), 0)
+	if err != nil {
+		return err
+	}
+	if nonHydrated.Transactions[0] != tx.Hash() {
+		return fmt.Errorf("first transaction hash of non-hydrated block %s does not match eth_getTransactionByBlockHashAndIndex %s",
+			nonHydrated.Transactions[0].Hex(), tx.Hash().Hex())
+	}
+	return nil
+}
+
+func RpcGetBlockByNumber(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(GetBlockByNumber); result != nil {
+		return result, nil
+	}
+
+	blkNum, err := rCtx.EthCli.BlockNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	blk, err := rCtx.EthCli.BlockByNumber(ctx, new(big.Int).SetUint64(blkNum))
+	if err != nil {
+		return nil, err
+	}
+	rCtx.BlockCache.Add(blkNum, blk)
+
+	// verify transactionsRoot of the latest block and a block including our transactions
+	if err = verifyTransactionsRoot(blk); err != nil {
+		return nil, err
+	}
+	if len(rCtx.BlockNumsIncludingTx) > 0 {
+		txBlk, err := rCtx.BlockByNumber(ctx, rCtx.BlockNumsIncludingTx[0])
+		if err != nil {
+			return nil, err
+		}
+		if err = verifyTransactionsRoot(txBlk); err != nil {
+			return nil, err
+		}
+	}
+	if err = verifyLogsBloom(ctx, rCtx); err != nil {
+		return nil, err
+	}
+
+	// sha3Uncles of a block without uncles must be the hash of an empty RLP list
+	if len(blk.Uncles()) == 0 && blk.UncleHash() != crypto.Keccak256Hash(rlp.EmptyList) {
+		return nil, errors.New("sha3Uncles is wrong for empty uncle list")
+	}
+
+	// query the block again with its hex encoded number and check the number round-trips
+	var rawBlk struct {
+		Number *hexutil.Big `json:"number"`
+		Nonce  string       `json:"nonce"`
+	}
+	if err = rCtx.EthCli.Client().CallContext(ctx, &rawBlk, string(GetBlockByNumber), hexutil.EncodeBig(blk.Number()), false); err != nil {
+		return nil, err
+	}
+	if rawBlk.Number == nil || rawBlk.Number.ToInt().Cmp(blk.Number()) != 0 {
+		return nil, fmt.Errorf("block queried by %s returns a different number %v", hexutil.EncodeBig(blk.Number()), rawBlk.Number)
+	}
+
+	var warnings []string
+	if gasLimit := blk.GasLimit(); gasLimit < rCtx.Conf.MinBlockGasLimit {
+		return nil, fmt.Errorf("block gas limit %d is less than min_block_gas_limit %d", gasLimit, rCtx.Conf.MinBlockGasLimit)
+	} else if gasLimit > rCtx.Conf.MaxBlockGasLimit {
+		warnings = append(warnings, fmt.Sprintf("block gas limit %d is greater than max_block_gas_limit %d", gasLimit, rCtx.Conf.MaxBlockGasLimit))
+	}
+
+	// mixHash holds prevRandao after the Merge, it is not checked for pre-Merge chains (MergeBlock 0)
+	if rCtx.Conf.MergeBlock != 0 && blkNum >= rCtx.Conf.MergeBlock && blk.MixDigest() == (common.Hash{}) {
+		warnings = append(warnings, fmt.Sprintf("mixHash of PoS block %d is zero, consensus layer may not provide prevRandao", blkNum))
+	}
+	// difficulty is always 0 on PoS chains, mixHash holding non-zero prevRandao indicates PoS
+	if rCtx.Conf.MergeBlock != 0 && blkNum >= rCtx.Conf.MergeBlock && blk.MixDigest() != (common.Hash{}) && blk.Difficulty().Sign() != 0 {
+		warnings = append(warnings, fmt.Sprintf("difficulty of PoS block %d is %s, expected 0", blkNum, blk.Difficulty()))
+	}
+	// extraData is limited to 32 bytes by the spec. Some EVM-compatible chains extend it for
+	// chain-specific metadata, which is a known deviation from the spec and still an error (see README)
+	if extra := blk.Extra(); len(extra) > 32 {
+		return nil, fmt.Errorf("extraData of block %d is %d bytes, exceeding 32 bytes limit of the spec", blkNum, len(extra))
+	}
+
+	// nonce is not used after the Merge, and geth serializes it as 8 bytes
+	if rCtx.Conf.MergeBlock != 0 && blkNum >= rCtx.Conf.MergeBlock && blk.Nonce() != 0 {
+		warnings = append(warnings, "block nonce should be zero on PoS chain")
+	}
+	if len(rawBlk.Nonce) != len("0x0000000000000000") {
+		warnings = append(warnings, fmt.Sprintf("block nonce %s is not serialized as 8 bytes", rawBlk.Nonce))
+	}
+	tdWarnings, err := checkTotalDifficulty(ctx, rCtx, blkNum)
+	if err != nil {
+		return nil, err
+	}
+	warnings = append(warnings, tdWarnings...)
+
+	if baseFee := blk.BaseFee(); baseFee == nil {
+		warnings = append(warnings, "chain may predate EIP-1559 or node doesn't implement baseFee")
+	} else if baseFee.Sign() < 0 {
+		return nil, fmt.Errorf("baseFeePerGas of block %d is negative: %s", blkNum, baseFee)
+	} else if feeHistory, err := rCtx.EthCli.FeeHistory(ctx, 1, blk.Number(), nil); err == nil && len(feeHistory.BaseFee) > 0 {
+		// baseFee of the first block of the fee history is the baseFee of the requested block
+		if feeHistory.BaseFee[0].Cmp(baseFee) != 0 {
+			return nil, fmt.Errorf("baseFeePerGas of block %d (%s) differs from eth_feeHistory (%s)", blkNum, baseFee, feeHistory.BaseFee[0])
+		}
+	}
+
+	// withdrawals must be an empty list rather than null after Shanghai even if there are no withdrawals
+	postShanghai := rCtx.Conf.ShanghaiBlock != 0 && blkNum >= rCtx.Conf.ShanghaiBlock
+	if postShanghai && blk.Withdrawals() == nil {
+		return nil, fmt.Errorf("withdrawals of block %d after Shanghai is null", blkNum)
+	} else if !postShanghai && blk.Withdrawals() != nil {
+		warnings = append(warnings, fmt.Sprintf("withdrawals of block %d before Shanghai is not null", blkNum))
+	}
+
+	// blobGasUsed and excessBlobGas are present after Cancun, and blobGasUsed is a multiple of the blob gas per blob
+	header := blk.Header()
+	if rCtx.Conf.CancunBlock != 0 && blkNum >= rCtx.Conf.CancunBlock && (header.BlobGasUsed == nil || header.ExcessBlobGas == nil) {
+		warnings = append(warnings, fmt.Sprintf("blobGasUsed or excessBlobGas of block %d after Cancun is missing", blkNum))
+	}
+	if header.BlobGasUsed != nil && *header.BlobGasUsed%params.BlobTxBlobGasPerBlob != 0 {
+		return nil, fmt.Errorf("blobGasUsed %d of block %d is not a multiple of %d", *header.BlobGasUsed, blkNum, params.BlobTxBlobGasPerBlob)
+	}
+
+	// parentBeaconBlockRoot references a different beacon block for each block after Dencun (EIP-4788)
+	if rCtx.Conf.DencunBlock != 0 && blkNum >= rCtx.Conf.DencunBlock {
+		if header.ParentBeaconRoot == nil || *header.ParentBeaconRoot == (common.Hash{}) {
+			return nil, fmt.Errorf("parentBeaconBlockRoot of block %d after Dencun is missing or zero", blkNum)
+		}
+	}
+	if rCtx.Conf.DencunBlock != 0 && blkNum > rCtx.Conf.DencunBlock {
+		parent, err := rCtx.BlockByNumber(ctx, blkNum-1)
+		if err != nil {
+			return nil, err
+		}
+		if parentRoot := parent.Header().ParentBeaconRoot; parentRoot != nil && *parentRoot == *header.ParentBeaconRoot {
+			return nil, fmt.Errorf("parentBeaconBlockRoot of block %d is the same as block %d: %s", blkNum, blkNum-1, parentRoot.Hex())
+		}
+	}
+
+	// withdrawalsRoot must be present together with withdrawals and match the root computed from them
+	withdrawalsHash := header.WithdrawalsHash
+	if (withdrawalsHash == nil) != (blk.Withdrawals() == nil) {
+		return nil, fmt.Errorf("withdrawalsRoot and withdrawals of block %d must be both present or both absent", blkNum)
+	}
+	if withdrawalsHash != nil {
+		if computed := gethtypes.DeriveSha(blk.Withdrawals(), trie.NewStackTrie(nil)); computed != *withdrawalsHash {
+			return nil, fmt.Errorf("block withdrawalsRoot does not match computed root from withdrawals (block %d: %s != %s)",
+				blkNum, withdrawalsHash.Hex(), computed.Hex())
+		}
+	}
+
+	return rCtx.RecordCustomResult(GetBlockByNumber, utils.MustBeautifyBlock(types.NewRpcBlock(blk)), warnings), nil
+}
+
+func RpcGetBlockWithSingleTx(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(GetBlockWithSingleTx); result != nil {
+		return result, nil
+	}
+
+	if len(rCtx.BlockNumsIncludingTx) == 0 {
+		return nil, errors.New("no blocks with transactions")
+	}
+
+	// ProcessedTransactions and BlockNumsIncludingTx are appended together by WaitForTx
+	for i, blkNum := range rCtx.BlockNumsIncludingTx {
+		blk, err := rCtx.BlockByNumber(ctx, blkNum)
+		if err != nil {
+			return nil, err
+		}
+		if len(blk.Transactions()) != 1 {
+			continue
+		}
+		txHash := rCtx.ProcessedTransactions[i]
+		if blk.Transactions()[0].Hash() != txHash {
+			return nil, fmt.Errorf("transaction of block %d is %s, expected %s", blkNum, blk.Transactions()[0].Hash().Hex(), txHash.Hex())
+		}
+		return rCtx.RecordCustomResult(GetBlockWithSingleTx, txHash.Hex(), nil), nil
+	}
+
+	// use the first one if none includes our transaction exclusively
+	blkNum, txHash := rCtx.BlockNumsIncludingTx[0], rCtx.ProcessedTransactions[0]
+	blk, err := rCtx.BlockByNumber(ctx, blkNum)
+	if err != nil {
+		return nil, err
+	}
+	if blk.Transaction(txHash) == nil {
+		return nil, fmt.Errorf("transaction %s is not found in block %d", txHash.Hex(), blkNum)
+	}
+	warnings := []string{"no single-transaction block was found"}
+
+	return rCtx.RecordCustomResult(GetBlockWithSingleTx, txHash.Hex(), warnings), nil
+}
+
+func RpcVerifyGasUsedPattern(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(GetBlockGasUsedPattern); result != nil {
+		return result, nil
+	}
+
+	if len(rCtx.BlockNumsIncludingTx) == 0 {
+		return nil, errors.New("no blocks with transactions")
+	}
+
+	// 3 consecutive blocks around the block including our transaction
+	txBlkNum := rCtx.BlockNumsIncludingTx[0]
+	latest, err := rCtx.EthCli.BlockNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+	from := txBlkNum
+	if from > 0 {
+		from--
+	}
+	if from+2 > latest && latest >= 2 {
+		from = latest - 2
+	}
+
+	var warnings []string
+	gasUsed := make(map[uint64]uint64)
+	for num := from; num <= from+2 && num <= latest; num++ {
+		blk, err := rCtx.BlockByNumber(ctx, num)
+		if err != nil {
+			return nil, err
+		}
+		gasUsed[num] = blk.GasUsed()
+		if blk.GasUsed() == blk.GasLimit() {
+			warnings = append(warnings, fmt.Sprintf("block %d is full (gasUsed == gasLimit %d), chain congestion may affect tests", num, blk.GasLimit()))
+		}
+	}
+	if gasUsed[txBlkNum] == 0 {
+		return nil, fmt.Errorf("gasUsed of block %d including our transaction is 0", txBlkNum)
+	}
+
+	return rCtx.RecordCustomResult(GetBlockGasUsedPattern, gasUsed, warnings), nil
+}
+
+// verifyTransactionsRoot recomputes the transactions trie root from the block transactions
+// and compares it against the transactionsRoot of the block header
+func verifyTransactionsRoot(blk *gethtypes.Block) error {
+	computed := gethtypes.DeriveSha(blk.Transactions(), trie.NewStackTrie(nil))
+	if computed != blk.TxHash() {
+		return fmt.Errorf("block transactionsRoot does not match computed root from transactions (block %d: %s != %s)",
+			blk.NumberU64(), blk.TxHash().Hex(), computed.Hex())
+	}
+	return nil
+}
+
+// checkTotalDifficulty checks totalDifficulty of the block is present and compares it with the previous block.
+// It must be constant after the Merge and must not decrease before the Merge.
+func checkTotalDifficulty(ctx context.Context, rCtx *RpcContext, blkNum uint64) ([]string, error) {
+	if blkNum == 0 {
+		return nil, nil
+	}
+	totalDifficulty := func(num uint64) (*big.Int, error) {
+		var raw map[string]interface{}
+		if err := rCtx.EthCli.Client().CallContext(ctx, &raw, string(GetBlockByNumber), hexutil.EncodeUint64(num), false); err != nil {
+			return nil, err
+		}
+		v, ok := raw["totalDifficulty"].(string)
+		if !ok {
+			return nil, nil
+		}
+		return hexutil.DecodeBig(v)
+	}
+
+	td, err := totalDifficulty(blkNum)
+	if err != nil {
+		return nil, err
+	}
+	if td == nil {
+		return []string{"totalDifficulty is missing or null"}, nil
+	}
+	prevTd, err := totalDifficulty(blkNum - 1)
+	if err != nil || prevTd == nil {
+		return nil, err
+	}
+
+	postMerge := rCtx.Conf.MergeBlock != 0 && blkNum-1 >= rCtx.Conf.MergeBlock
+	switch {
+	case postMerge && td.Cmp(prevTd) != 0:
+		return []string{fmt.Sprintf("totalDifficulty changes after the Merge: %s at block %d, %s at block %d", prevTd, blkNum-1, td, blkNum)}, nil
+	case td.Cmp(prevTd) < 0:
+		return []string{fmt.Sprintf("totalDifficulty decreases: %s at block %d, %s at block %d", prevTd, blkNum-1, td, blkNum)}, nil
+	}
+	return nil, nil
+}
+
+// verifyLogsBloom finds a block including the ERC20 Transfer event and compares
+// the logsBloom of the block header against the bloom computed from the block receipts
+func verifyLogsBloom(ctx context.Context, rCtx *RpcContext) error {
+	if rCtx.ERC20Abi == nil {
+		return nil
+	}
+	transferTopic := rCtx.ERC20Abi.Events["Transfer"].ID
+	for _, blkNum := range rCtx.BlockNumsIncludingTx {
+		rpcBlockNum := rpc.BlockNumber(blkNum)
+		receipts, err := rCtx.EthCli.BlockReceipts(ctx, rpc.BlockNumberOrHash{BlockNumber: &rpcBlockNum})
+		if err != nil {
+			return err
+		}
+		hasTransfer := false
+		for _, receipt := range receipts {
+			for _, l := range receipt.Logs {
+				if len(l.Topics) > 0 && l.Topics[0] == transferTopic {
+					hasTransfer = true
+				}
+			}
+		}
+		if !hasTransfer {
+			continue
+		}
+
+		blk, err := rCtx.BlockByNumber(ctx, blkNum)
+		if err != nil {
+			return err
+		}
+		bloom := blk.Header().Bloom
+		computed := gethtypes.CreateBloom(gethtypes.Receipts(receipts))
+		if !bytes.Equal(computed.Bytes(), bloom.Bytes()) || !bloom.Test(transferTopic.Bytes()) {
+			return errors.New("block logsBloom does not match computed bloom from receipts")
+		}
+		return nil
+	}
+	return nil
+}
+
+// RpcGetEarliestBlock queries eth_getBlockByNumber with the earliest tag, which must be the genesis block
+func RpcGetEarliestBlock(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(GetEarliestBlock); result != nil {
+		return result, nil
+	}
+	return getBlockByTag(ctx, rCtx, GetEarliestBlock, "earliest")
+}
+
+// RpcGetSafeBlock queries eth_getBlockByNumber with the safe tag
+func RpcGetSafeBlock(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(GetSafeBlock); result != nil {
+		return result, nil
+	}
+	return getBlockByTag(ctx, rCtx, GetSafeBlock, "safe")
+}
+
+// RpcGetFinalizedBlock queries eth_getBlockByNumber with the finalized tag
+func RpcGetFinalizedBlock(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(GetFinalizedBlock); result != nil {
+		return result, nil
+	}
+	return getBlockByTag(ctx, rCtx, GetFinalizedBlock, "finalized")
+}
+
+// getBlockByTag queries eth_getBlockByNumber with the block tag and records the result
+func getBlockByTag(ctx context.Context, rCtx *RpcContext, name types.RpcName, tag string) (*types.RpcResult, error) {
+	var raw json.RawMessage
+	if err := rCtx.EthCli.Client().CallContext(ctx, &raw, string(GetBlockByNumber), tag, false); err != nil {
+		return nil, err
+	}
+
+	if len(raw) == 0 || string(raw) == "null" {
+		if tag == "earliest" {
+			return nil, errors.New("earliest block is null")
+		}
+		// post-merge chains must support safe and finalized, but some do not
+		return rCtx.RecordCustomResult(name, "null", []string{fmt.Sprintf("node returns null for %s block", tag)}), nil
+	}
+
+	var blk struct {
+		Number *hexutil.Big `json:"number"`
+		Hash   common.Hash  `json:"hash"`
+	}
+	if err := json.Unmarshal(raw, &blk); err != nil {
+		return nil, err
+	}
+	if blk.Number == nil {
+		return nil, fmt.Errorf("%s block has no number", tag)
+	}
+	if tag == "earliest" && blk.Number.ToInt().Sign() != 0 {
+		return nil, fmt.Errorf("earliest block number is %s, expected 0", blk.Number.ToInt())
+	}
+
+	return rCtx.RecordCustomResult(name, fmt.Sprintf("number: %s, hash: %s", blk.Number.ToInt(), blk.Hash.Hex()), nil), nil
+}
+
+func RpcGetPendingBlock(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(GetPendingBlock); result != nil {
+		return result, nil
+	}
+
+	latest, err := rCtx.EthCli.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw json.RawMessage
+	if err = rCtx.EthCli.Client().CallContext(ctx, &raw, string(GetBlockByNumber), "pending", false); err != nil {
+		return nil, err
+	}
+
+	if len(raw) == 0 || string(raw) == "null" {
+		return rCtx.RecordCustomResult(GetPendingBlock, "null", []string{"node does not expose pending block"}), nil
+	}
+
+	var pending struct {
+		Number     *hexutil.Big `json:"number"`
+		ParentHash common.Hash  `json:"parentHash"`
+	}
+	if err = json.Unmarshal(raw, &pending); err != nil {
+		return nil, err
+	}
+	if pending.Number == nil {
+		return nil, errors.New("pending block has no number")
+	}
+
+	pendingNum := pending.Number.ToInt()
+	if pendingNum.Cmp(latest.Number) < 0 {
+		return nil, fmt.Errorf("pending block number %s is lower than latest block number %s", pendingNum, latest.Number)
+	}
+
+	var warnings []string
+	switch new(big.Int).Sub(pendingNum, latest.Number).Int64() {
+	case 0:
+		warnings = append(warnings, "pending block is the same as latest block")
+	case 1:
+		if pending.ParentHash != latest.Hash() {
+			err = fmt.Errorf("parent hash of pending block %s does not match latest block hash %s", pending.ParentHash.Hex(), latest.Hash().Hex())
+			rCtx.RecordResult(&types.RpcResult{Method: GetPendingBlockParentHash, Status: types.Error, ErrMsg: err.Error()})
+			return nil, err
+		}
+		rCtx.RecordCustomResult(GetPendingBlockParentHash, pending.ParentHash.Hex(), nil)
+	}
+
+	return rCtx.RecordCustomResult(GetPendingBlock, string(raw), warnings), nil
+}
+
+func RpcSendRawTransactionTransferValue(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	// testedRPCs is a slice of RpcResult that will be recorded to rCtx.TestedRPCs
+	// if the transaction is successfully sent
+	var testedRPCs []*types.RpcResult
+	var err error
+	// Create a new transaction
+	if rCtx.ChainId, err = rCtx.EthCli.ChainID(ctx); err != nil {
+		return nil, err
+	}
+	testedRPCs = append(testedRPCs, chainIdResult(rCtx, rCtx.ChainId))
+
+	nonce, err := rCtx.EthCli.PendingNonceAt(ctx, rCtx.Acc.Address)
+	if err != nil {
+		return nil, err
+	}
+	testedRPCs = append(testedRPCs, &types.RpcResult{
+		Method: GetTransactionCount,
+		Status: types.Ok,
+		Value:  nonce,
+	})
+
+	if rCtx.MaxPriorityFeePerGas, err = rCtx.EthCli.SuggestGasTipCap(ctx); err != nil {
+		return nil, err
+	}
+	testedRPCs = append(testedRPCs, &types.RpcResult{
+		Method: GetMaxPriorityFeePerGas,
+		Status: types.Ok,
+		Value:  rCtx.MaxPriorityFeePerGas.String(),
+	})
+	if rCtx.GasPrice, err = rCtx.EthCli.SuggestGasPrice(ctx); err != nil {
+		return nil, err
+	}
+	testedRPCs = append(testedRPCs, &types.RpcResult{
+		Method: GetGasPrice,
+		Status: types.Ok,
+		Value:  rCtx.GasPrice.String(),
+	})
+
+	randomRecipient := rCtx.TempAccount().Address
+	value := new(big.Int).SetUint64(1)
+	balanceBeforeSend, err := rCtx.EthCli.BalanceAt(ctx, rCtx.Acc.Address, nil)
+	if err != nil {
+		return nil, err
+	}
+	testedRPCs = append(testedRPCs, &types.RpcResult{
+		Method: GetBalance,
+		Status: types.Ok,
+		Value:  balanceBeforeSend.String(),
+	})
+
+	if balanceBeforeSend.Cmp(value) < 0 {
+		return nil, errors.New("insufficient balanceBeforeSend")
+	}
+
+	tx := gethtypes.NewTx(&gethtypes.DynamicFeeTx{
+		ChainID:   rCtx.ChainId,
+		Nonce:     nonce,
+		GasTipCap: rCtx.MaxPriorityFeePerGas,
+		GasFeeCap: new(big.Int).Add(rCtx.GasPrice, big.NewInt(1000000000)),
+		Gas:       21000, // fixed gas limit for transfer
+		To:        &randomRecipient,
+		Value:     value,
+	})
+
+	// TODO: Make signer using types.MakeSigner with chain params
+	signer := gethtypes.NewLondonSigner(rCtx.ChainId)
+	signedTx, err := gethtypes.SignTx(tx, signer, rCtx.Acc.PrivKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = rCtx.EthCli.SendTransaction(ctx, signedTx); err != nil {
+		return nil, err
+	}
+	result := &types.RpcResult{
+		Method: SendRawTransaction,
+		Status: types.Ok,
+		Value:  signedTx.Hash().Hex(),
+	}
+	testedRPCs = append(testedRPCs, result)
+
+	// query the transaction before it is mined
+	pendingResult, err := checkPendingTransaction(ctx, rCtx, signedTx.Hash())
+	if err != nil {
+		return nil, err
+	}
+	testedRPCs = append(testedRPCs, pendingResult)
+
+	// wait for the transaction to be mined
+	tout, _ := time.ParseDuration(rCtx.Conf.Timeout)
+	if err = WaitForTx(ctx, rCtx, signedTx.Hash(), tout); err != nil {
+		return nil, err
+	}
+
+	balance, err := rCtx.EthCli.BalanceAt(ctx, rCtx.Acc.Address, nil)
 	if err != nil {
 		return nil, err
 	}
 	// check if the balance decreased by the value of the transaction (+ gas fee)
-	if new(big.Int).Sub(balanceBeforeSend, balance).Cmp(value) < 0 {
-		return nil, errors.New("balanceBeforeSend mismatch, maybe the transaction was not mined or implementation is incorrect")
+	decreased := new(big.Int).Sub(balanceBeforeSend, balance)
+	if decreased.Cmp(value) < 0 {
+		err = errors.New("balanceBeforeSend mismatch, maybe the transaction was not mined or implementation is incorrect")
+		rCtx.RecordResult(&types.RpcResult{Method: GetBalanceAfterTransfer, Status: types.Error, ErrMsg: err.Error()})
+		return nil, err
+	}
+	for _, testedRPC := range testedRPCs {
+		rCtx.RecordResult(testedRPC)
+	}
+	rCtx.RecordCustomResult(GetBalanceAfterTransfer, fmt.Sprintf("decreased by %s (value: %s)", decreased, value), nil)
+
+	return result, nil
+}
+
+// checkPendingTransaction queries a transaction which is just sent and verifies it is returned as pending
+func checkPendingTransaction(ctx context.Context, rCtx *RpcContext, txHash common.Hash) (*types.RpcResult, error) {
+	var tx map[string]interface{}
+	if err := rCtx.EthCli.Client().CallContext(ctx, &tx, string(GetTransactionByHash), txHash); err != nil {
+		return nil, err
+	}
+
+	var warnings []string
+	if tx == nil {
+		warnings = append(warnings, "pending transaction is not found")
+	} else {
+		// the transaction may be already mined on chains with instant finality, so these are warnings only
+		if tx["blockHash"] != nil {
+			warnings = append(warnings, "blockHash of pending transaction is not null")
+		}
+		if tx["blockNumber"] != nil {
+			warnings = append(warnings, "blockNumber of pending transaction is not null")
+		}
+		if hash, _ := tx["hash"].(string); common.HexToHash(hash) != txHash {
+			warnings = append(warnings, fmt.Sprintf("hash of pending transaction mismatch: %s != %s", hash, txHash.Hex()))
+		}
+	}
+
+	status := types.Ok
+	if len(warnings) > 0 {
+		status = types.Warning
+	}
+
+	return &types.RpcResult{
+		Method:   GetTransactionByHashPending,
+		Status:   status,
+		Value:    txHash.Hex(),
+		Warnings: warnings,
+	}, nil
+}
+
+func RpcSendRawTransactionDeployContract(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	// testedRPCs is a slice of RpcResult that will be recorded to rCtx.TestedRPCs
+	// if the transaction is successfully sent
+	var testedRPCs []*types.RpcResult
+	var err error
+	// Create a new transaction
+	if rCtx.ChainId, err = rCtx.EthCli.ChainID(ctx); err != nil {
+		return nil, err
+	}
+	testedRPCs = append(testedRPCs, chainIdResult(rCtx, rCtx.ChainId))
+
+	nonce, err := rCtx.EthCli.PendingNonceAt(ctx, rCtx.Acc.Address)
+	if err != nil {
+		return nil, err
+	}
+	testedRPCs = append(testedRPCs, &types.RpcResult{
+		Method: GetTransactionCount,
+		Status: types.Ok,
+		Value:  nonce,
+	})
+
+	if rCtx.MaxPriorityFeePerGas, err = rCtx.EthCli.SuggestGasTipCap(ctx); err != nil {
+		return nil, err
+	}
+	testedRPCs = append(testedRPCs, &types.RpcResult{
+		Method: GetMaxPriorityFeePerGas,
+		Status: types.Ok,
+		Value:  rCtx.MaxPriorityFeePerGas.String(),
+	})
+	if rCtx.GasPrice, err = rCtx.EthCli.SuggestGasPrice(ctx); err != nil {
+		return nil, err
+	}
+	testedRPCs = append(testedRPCs, &types.RpcResult{
+		Method: GetGasPrice,
+		Status: types.Ok,
+		Value:  rCtx.GasPrice.String(),
+	})
+
+	tx := gethtypes.NewTx(&gethtypes.DynamicFeeTx{
+		ChainID:   rCtx.ChainId,
+		Nonce:     nonce,
+		GasTipCap: rCtx.MaxPriorityFeePerGas,
+		GasFeeCap: new(big.Int).Add(rCtx.GasPrice, big.NewInt(1000000000)),
+		Gas:       10000000,
+		Data:      common.FromHex(hex.EncodeToString(contracts.ContractByteCode)),
+	})
+
+	// TODO: Make signer using types.MakeSigner with chain params
+	signer := gethtypes.NewLondonSigner(rCtx.ChainId)
+	signedTx, err := gethtypes.SignTx(tx, signer, rCtx.Acc.PrivKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = rCtx.EthCli.SendTransaction(ctx, signedTx); err != nil {
+		return nil, err
+	}
+	result := &types.RpcResult{
+		Method: SendRawTransactionDeployContract,
+		Status: types.Ok,
+		Value:  signedTx.Hash().Hex(),
+	}
+	testedRPCs = append(testedRPCs, result)
+
+	// wait for the transaction to be mined
+	tout, _ := time.ParseDuration(rCtx.Conf.Timeout)
+	if err = WaitForTx(ctx, rCtx, signedTx.Hash(), tout); err != nil {
+		return nil, err
+	}
+
+	// WaitForTx fails on a reverted deployment, so the receipt is of a successful one
+	receipt, err := rCtx.EthCli.TransactionReceipt(ctx, signedTx.Hash())
+	if err != nil {
+		return nil, err
+	}
+	if receipt.ContractAddress == (common.Address{}) {
+		return nil, errors.New("contract address is empty, failed to deploy")
+	}
+	rCtx.ERC20Addr = receipt.ContractAddress
+	rCtx.ERC20DeployBlockNum = receipt.BlockNumber.Uint64()
+	rCtx.ERC20DeployReceipt = receipt
+
+	for _, testedRPC := range testedRPCs {
+		rCtx.RecordResult(testedRPC)
+	}
+
+	return result, nil
+}
+
+func RpcSendRawTransactionTransferERC20(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	// testedRPCs is a slice of RpcResult that will be recorded to rCtx.TestedRPCs
+	// if the transaction is successfully sent
+	var testedRPCs []*types.RpcResult
+	var err error
+	// Create a new transaction
+	if rCtx.ChainId, err = rCtx.EthCli.ChainID(ctx); err != nil {
+		return nil, err
+	}
+	testedRPCs = append(testedRPCs, chainIdResult(rCtx, rCtx.ChainId))
+
+	nonce, err := rCtx.EthCli.PendingNonceAt(ctx, rCtx.Acc.Address)
+	if err != nil {
+		return nil, err
+	}
+	testedRPCs = append(testedRPCs, &types.RpcResult{
+		Method: GetTransactionCount,
+		Status: types.Ok,
+		Value:  nonce,
+	})
+
+	if rCtx.MaxPriorityFeePerGas, err = rCtx.EthCli.SuggestGasTipCap(ctx); err != nil {
+		return nil, err
+	}
+	testedRPCs = append(testedRPCs, &types.RpcResult{
+		Method: GetMaxPriorityFeePerGas,
+		Status: types.Ok,
+		Value:  rCtx.MaxPriorityFeePerGas.String(),
+	})
+	if rCtx.GasPrice, err = rCtx.EthCli.SuggestGasPrice(ctx); err != nil {
+		return nil, err
+	}
+	testedRPCs = append(testedRPCs, &types.RpcResult{
+		Method: GetGasPrice,
+		Status: types.Ok,
+		Value:  rCtx.GasPrice.String(),
+	})
+
+	randomRecipient := rCtx.TempAccount().Address
+	data, err := rCtx.ERC20Abi.Pack("transfer", randomRecipient, new(big.Int).SetUint64(1))
+	if err != nil {
+		log.Fatalf("Failed to pack transaction data: %v", err)
+	}
+
+	// Erc20 transfer
+	tx := gethtypes.NewTx(&gethtypes.DynamicFeeTx{
+		ChainID:   rCtx.ChainId,
+		Nonce:     nonce,
+		GasTipCap: rCtx.MaxPriorityFeePerGas,
+		GasFeeCap: new(big.Int).Add(rCtx.GasPrice, big.NewInt(1000000000)),
+		Gas:       10000000,
+		To:        &rCtx.ERC20Addr,
+		Data:      data,
+	})
+
+	// TODO: Make signer using types.MakeSigner with chain params
+	signer := gethtypes.NewLondonSigner(rCtx.ChainId)
+	signedTx, err := gethtypes.SignTx(tx, signer, rCtx.Acc.PrivKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = rCtx.EthCli.SendTransaction(ctx, signedTx); err != nil {
+		return nil, err
+	}
+
+	result := &types.RpcResult{
+		Method: SendRawTransactionTransferERC20,
+		Status: types.Ok,
+		Value:  signedTx.Hash().Hex(),
+	}
+	testedRPCs = append(testedRPCs, result)
+
+	// wait for the transaction to be mined
+	tout, _ := time.ParseDuration(rCtx.Conf.Timeout)
+	if err = WaitForTx(ctx, rCtx, signedTx.Hash(), tout); err != nil {
+		return nil, err
+	}
+
+	for _, testedRPC := range testedRPCs {
+		rCtx.RecordResult(testedRPC)
+	}
+
+	return result, nil
+}
+
+func RpcSendRawTransactionMintERC20(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(SendRawTransactionMintERC20); result != nil {
+		return result, nil
+	}
+
+	if _, ok := rCtx.ERC20Abi.Methods["mint"]; !ok {
+		// the deployed contract does not support minting
+		result := &types.RpcResult{
+			Method: SendRawTransactionMintERC20,
+			Status: types.Skipped,
+			Value:  "mint is not found in the ERC20 ABI",
+		}
+		rCtx.RecordResult(result)
+		return result, nil
+	}
+
+	if rCtx.ERC20Addr == (common.Address{}) {
+		return nil, errors.New("no contract address, must be deployed first")
+	}
+
+	totalSupplyBefore, err := erc20TotalSupply(ctx, rCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	amount := new(big.Int).SetUint64(1)
+	data, err := rCtx.ERC20Abi.Pack("mint", rCtx.Acc.Address, amount)
+	if err != nil {
+		log.Fatalf("Failed to pack transaction data: %v", err)
+	}
+
+	signedTx, err := signAndSendTx(ctx, rCtx, &rCtx.ERC20Addr, data, nil, 10000000)
+	if err != nil {
+		return nil, err
+	}
+
+	// wait for the transaction to be mined
+	tout, _ := time.ParseDuration(rCtx.Conf.Timeout)
+	if err = WaitForTx(ctx, rCtx, signedTx.Hash(), tout); err != nil {
+		return nil, err
+	}
+
+	totalSupplyAfter, err := erc20TotalSupply(ctx, rCtx)
+	if err != nil {
+		return nil, err
+	}
+	if new(big.Int).Sub(totalSupplyAfter, totalSupplyBefore).Cmp(amount) != 0 {
+		return nil, fmt.Errorf("totalSupply is not increased by minted amount: before %s, after %s, minted %s",
+			totalSupplyBefore, totalSupplyAfter, amount)
+	}
+
+	return rCtx.RecordCustomResult(SendRawTransactionMintERC20, signedTx.Hash().Hex(), nil), nil
+}
+
+func RpcSendRawTransactionSelfTransfer(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(SendRawTransactionSelfTransfer); result != nil {
+		return result, nil
+	}
+
+	balanceBefore, err := rCtx.EthCli.BalanceAt(ctx, rCtx.Acc.Address, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// send 0 wei to the sender itself
+	signedTx, err := signAndSendTx(ctx, rCtx, &rCtx.Acc.Address, nil, big.NewInt(0), 21000)
+	if err != nil {
+		return rCtx.RecordCustomResult(SendRawTransactionSelfTransfer, nil,
+			[]string{fmt.Sprintf("node rejects zero-value self transfer: %v", err)}), nil
+	}
+
+	tout, _ := time.ParseDuration(rCtx.Conf.Timeout)
+	if err = WaitForTx(ctx, rCtx, signedTx.Hash(), tout); err != nil {
+		return nil, err
+	}
+	receipt, err := rCtx.EthCli.TransactionReceipt(ctx, signedTx.Hash())
+	if err != nil {
+		return nil, err
+	}
+
+	balanceAfter, err := rCtx.EthCli.BalanceAt(ctx, rCtx.Acc.Address, receipt.BlockNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	// balance must be decreased by exactly the gas cost
+	gasCost := new(big.Int).Mul(new(big.Int).SetUint64(receipt.GasUsed), receipt.EffectiveGasPrice)
+	if spent := new(big.Int).Sub(balanceBefore, balanceAfter); spent.Cmp(gasCost) != 0 {
+		return nil, fmt.Errorf("balance is decreased by %s after self transfer, expected gas cost %s", spent, gasCost)
+	}
+
+	return rCtx.RecordCustomResult(SendRawTransactionSelfTransfer, signedTx.Hash().Hex(), nil), nil
+}
+
+// signAndSendTx signs a dynamic fee transaction from the rich account and sends it
+// using the chain id and gas prices fetched by previous transactions
+func signAndSendTx(ctx context.Context, rCtx *RpcContext, to *common.Address, data []byte, value *big.Int, gas uint64) (*gethtypes.Transaction, error) {
+	if rCtx.ChainId == nil || rCtx.MaxPriorityFeePerGas == nil || rCtx.GasPrice == nil {
+		return nil, errors.New("chain id and gas prices are not fetched, must send a transaction first")
+	}
+
+	nonce, err := rCtx.EthCli.PendingNonceAt(ctx, rCtx.Acc.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := gethtypes.NewTx(&gethtypes.DynamicFeeTx{
+		ChainID:   rCtx.ChainId,
+		Nonce:     nonce,
+		GasTipCap: rCtx.MaxPriorityFeePerGas,
+		GasFeeCap: new(big.Int).Add(rCtx.GasPrice, big.NewInt(1000000000)),
+		Gas:       gas,
+		To:        to,
+		Value:     value,
+		Data:      data,
+	})
+
+	// TODO: Make signer using types.MakeSigner with chain params
+	signer := gethtypes.NewLondonSigner(rCtx.ChainId)
+	signedTx, err := gethtypes.SignTx(tx, signer, rCtx.Acc.PrivKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = rCtx.EthCli.SendTransaction(ctx, signedTx); err != nil {
+		return nil, err
+	}
+	return signedTx, nil
+}
+
+// erc20TotalSupply returns the totalSupply of the deployed ERC20 contract via eth_call
+func erc20TotalSupply(ctx context.Context, rCtx *RpcContext) (*big.Int, error) {
+	data, err := rCtx.ERC20Abi.Pack("totalSupply")
+	if err != nil {
+		log.Fatalf("Failed to pack transaction data: %v", err)
+	}
+
+	res, err := rCtx.EthCli.CallContract(ctx, ethereum.CallMsg{To: &rCtx.ERC20Addr, Data: data}, nil)
+	if err != nil {
+		return nil, err
+	}
+	out, err := rCtx.ERC20Abi.Unpack("totalSupply", res)
+	if err != nil {
+		return nil, err
+	}
+	return out[0].(*big.Int), nil
+}
+
+func RpcGetBlockReceipts(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(GetBlockReceipts); result != nil {
+		return result, nil
+	}
+
+	if len(rCtx.BlockNumsIncludingTx) == 0 {
+		return nil, errors.New("no blocks with transactions")
+
+	}
+
+	// TODO: Random pick
+	// pick a block with transactions
+	blkNum := rCtx.BlockNumsIncludingTx[0]
+	rpcBlockNum := rpc.BlockNumber(blkNum)
+	receipts, err := rCtx.EthCli.BlockReceipts(ctx, rpc.BlockNumberOrHash{BlockNumber: &rpcBlockNum})
+	if err != nil {
+		return nil, err
+	}
+	rCtx.LastBlockReceipts = receipts
+
+	// verify receiptsRoot of the block header against the root computed from the receipts
+	if _, err = RpcGetBlockByNumber(ctx, rCtx); err != nil {
+		return nil, errors.New("eth_getBlockByNumber must be succeeded before checking receiptsRoot")
+	}
+	header, err := rCtx.EthCli.HeaderByNumber(ctx, new(big.Int).SetUint64(blkNum))
+	if err != nil {
+		return nil, err
+	}
+	if computed := gethtypes.DeriveSha(gethtypes.Receipts(receipts), trie.NewStackTrie(nil)); computed != header.ReceiptHash {
+		return nil, fmt.Errorf("block receiptsRoot does not match computed root from receipts (block %d: %s != %s)",
+			blkNum, header.ReceiptHash.Hex(), computed.Hex())
+	}
+
+	// query the latest block receipts with the latest tag via ethclient and as a raw string
+	var warnings []string
+	latestNum := rpc.LatestBlockNumber
+	latestReceipts, err := rCtx.EthCli.BlockReceipts(ctx, rpc.BlockNumberOrHash{BlockNumber: &latestNum})
+	if err != nil {
+		warnings = append(warnings, fmt.Sprintf("eth_getBlockReceipts may not support special block tags: %v", err))
+	} else {
+		var rawLatestReceipts []*gethtypes.Receipt
+		if err = rCtx.EthCli.Client().CallContext(ctx, &rawLatestReceipts, string(GetBlockReceipts), "latest"); err != nil {
+			warnings = append(warnings, fmt.Sprintf("eth_getBlockReceipts may not support special block tags: %v", err))
+		} else if err = compareLatestReceipts(latestReceipts, rawLatestReceipts); err != nil {
+			return nil, err
+		}
+	}
+
+	return rCtx.RecordCustomResult(GetBlockReceipts, utils.MustBeautifyReceipts(receipts), warnings), nil
+}
+
+// compareLatestReceipts compares receipts of the latest block fetched twice.
+// They are not compared if a new block is mined between the two queries.
+func compareLatestReceipts(a, b []*gethtypes.Receipt) error {
+	if len(a) > 0 && len(b) > 0 && a[0].BlockHash != b[0].BlockHash {
+		return nil
+	}
+	if len(a) != len(b) {
+		return fmt.Errorf("eth_getBlockReceipts with latest tag returns %d receipts, but raw latest tag returns %d", len(a), len(b))
+	}
+	for i := range a {
+		if a[i].TxHash != b[i].TxHash {
+			return fmt.Errorf("receipt %d of latest block differs: %s != %s", i, a[i].TxHash.Hex(), b[i].TxHash.Hex())
+		}
+	}
+	return nil
+}
+
+func RpcGetTransactionByHash(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(GetTransactionByHash); result != nil {
+		return result, nil
+	}
+
+	if len(rCtx.ProcessedTransactions) == 0 {
+		return nil, errors.New("no transactions")
+	}
+
+	// TODO: Random pick
+	txHash := rCtx.ProcessedTransactions[0]
+	tx, _, err := rCtx.EthCli.TransactionByHash(ctx, txHash)
+	if err != nil {
+		return nil, err
+	}
+
+	var rawTx map[string]interface{}
+	if err = rCtx.EthCli.Client().CallContext(ctx, &rawTx, string(GetTransactionByHash), txHash); err != nil {
+		return nil, err
+	}
+	warnings := validateTransactionFields(rawTx, txHash)
+
+	// accessList must be present, possibly empty, for EIP-2930 and EIP-1559 transactions
+	if tx.Type() == gethtypes.AccessListTxType || tx.Type() == gethtypes.DynamicFeeTxType {
+		if _, exists := rawTx["accessList"]; !exists {
+			warnings = append(warnings, fmt.Sprintf("accessList is missing for type %d transaction", tx.Type()))
+		}
+	}
+
+	return rCtx.RecordCustomResult(GetTransactionByHash, utils.MustBeautifyTransaction(tx), warnings), nil
+}
+
+// validateTransactionFields checks that all required fields of a raw confirmed transaction are present and valid,
+// returning one warning per missing or invalid field
+func validateTransactionFields(raw map[string]interface{}, txHash common.Hash) []string {
+	var warnings []string
+	str := func(field string) (string, bool) {
+		v, ok := raw[field].(string)
+		if !ok {
+			warnings = append(warnings, fmt.Sprintf("%s is missing or not a string", field))
+		}
+		return v, ok
+	}
+
+	if v, ok := str("hash"); ok {
+		if b, err := hexutil.Decode(v); err != nil || len(b) != common.HashLength {
+			warnings = append(warnings, fmt.Sprintf("hash %s is not 32 bytes", v))
+		} else if common.BytesToHash(b) != txHash {
+			warnings = append(warnings, fmt.Sprintf("hash %s does not match queried hash %s", v, txHash.Hex()))
+		}
+	}
+	if v, ok := str("nonce"); ok {
+		if _, err := hexutil.DecodeUint64(v); err != nil {
+			warnings = append(warnings, fmt.Sprintf("nonce %s is not numeric", v))
+		}
+	}
+	// the transaction is confirmed, so block fields must not be null
+	str("blockHash")
+	str("blockNumber")
+	if v, ok := str("from"); ok && !common.IsHexAddress(v) {
+		warnings = append(warnings, fmt.Sprintf("from %s is not a valid address", v))
+	}
+	if v, ok := str("gas"); ok {
+		if n, err := hexutil.DecodeUint64(v); err != nil || n == 0 {
+			warnings = append(warnings, fmt.Sprintf("gas %s is not positive", v))
+		}
+	}
+	if v, ok := str("value"); ok {
+		if n, err := hexutil.DecodeBig(v); err != nil || n.Sign() < 0 {
+			warnings = append(warnings, fmt.Sprintf("value %s is not a non-negative number", v))
+		}
+	}
+	if v, ok := str("input"); ok {
+		if _, err := hexutil.Decode(v); err != nil {
+			warnings = append(warnings, fmt.Sprintf("input %s is not valid hex", v))
+		}
+	}
+	str("v")
+	str("r")
+	str("s")
+	return warnings
+}
+
+func RpcGetTransactionByBlockHashAndIndex(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(GetTransactionByBlockHashAndIndex); result != nil {
+		return result, nil
+	}
+
+	if len(rCtx.BlockNumsIncludingTx) == 0 {
+		return nil, errors.New("no blocks with transactions")
+	}
+
+	// TODO: Random pick
+	blkNum := rCtx.BlockNumsIncludingTx[0]
+	blk, err := rCtx.BlockByNumber(ctx, blkNum)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(blk.Transactions()) == 0 {
+		return nil, errors.New("no transactions in the block")
+	}
+
+	tx, err := rCtx.EthCli.TransactionInBlock(ctx, blk.Hash(), 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return rCtx.RecordCustomResult(GetTransactionByBlockHashAndIndex, utils.MustBeautifyTransaction(tx), nil), nil
+}
+
+func RpcGetAllTransactionsByBlockHash(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(GetAllTransactionsByBlockHash); result != nil {
+		return result, nil
+	}
+
+	if len(rCtx.BlockNumsIncludingTx) == 0 {
+		return nil, errors.New("no blocks with transactions")
+	}
+
+	blk, err := rCtx.BlockByNumber(ctx, rCtx.BlockNumsIncludingTx[0])
+	if err != nil {
+		return nil, err
+	}
+
+	for i, blockTx := range blk.Transactions() {
+		var tx struct {
+			Hash             common.Hash    `json:"hash"`
+			TransactionIndex hexutil.Uint64 `json:"transactionIndex"`
+		}
+		if err = rCtx.EthCli.Client().CallContext(ctx, &tx, string(GetTransactionByBlockHashAndIndex), blk.Hash(), hexutil.Uint64(i)); err != nil {
+			return nil, err
+		}
+		if tx.Hash != blockTx.Hash() {
+			return nil, fmt.Errorf("transaction hash at index %d is %s, but block has %s", i, tx.Hash.Hex(), blockTx.Hash().Hex())
+		}
+		if uint64(tx.TransactionIndex) != uint64(i) {
+			return nil, fmt.Errorf("transactionIndex of transaction at index %d is %d", i, tx.TransactionIndex)
+		}
+	}
+
+	return rCtx.RecordCustomResult(GetAllTransactionsByBlockHash, len(blk.Transactions()), nil), nil
+}
+
+func RpcGetTransactionByBlockNumberAndIndex(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(GetTransactionByBlockNumberAndIndex); result != nil {
+		return result, nil
+	}
+
+	if len(rCtx.BlockNumsIncludingTx) == 0 {
+		return nil, errors.New("no blocks with transactions")
+	}
+
+	// TODO: Random pick
+	blkNum := rCtx.BlockNumsIncludingTx[0]
+	var tx gethtypes.Transaction
+	if err := rCtx.EthCli.Client().CallContext(ctx, &tx, string(GetTransactionByBlockNumberAndIndex), blkNum, "0x0"); err != nil {
+		return nil, err
+	}
+
+	return rCtx.RecordCustomResult(GetTransactionByBlockNumberAndIndex, utils.MustBeautifyTransaction(&tx), nil), nil
+}
+
+func RpcGetTransactionCountByHash(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(GetTransactionCountByHash); result != nil {
+		return result, nil
+	}
+
+	if len(rCtx.BlockNumsIncludingTx) == 0 {
+		return nil, errors.New("no transactions")
+	}
+
+	// get block
+	blkNum := rCtx.BlockNumsIncludingTx[0]
+	blk, err := rCtx.BlockByNumber(ctx, blkNum)
+	if err != nil {
+		return nil, err
+	}
+
+	var count uint64
+	if err = rCtx.EthCli.Client().CallContext(ctx, &count, string(GetTransactionCountByHash), blk.Hash()); err != nil {
+		return nil, err
+	}
+
+	return rCtx.RecordCustomResult(GetTransactionCountByHash, count, nil), nil
+}
+
+func RpcGetTransactionReceipt(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(GetTransactionReceipt); result != nil {
+		return result, nil
+	}
+
+	if len(rCtx.ProcessedTransactions) == 0 {
+		return nil, errors.New("no transactions")
 	}
-	rCtx.AlreadyTestedRPCs = append(rCtx.AlreadyTestedRPCs, testedRPCs...)
 
-	return result, nil
+	txHash := rCtx.ProcessedTransactions[0]
+	receipt, err := rCtx.EthCli.TransactionReceipt(ctx, txHash)
+	if err != nil {
+		return nil, err
+	}
+
+	var rawReceipt map[string]interface{}
+	if err = rCtx.EthCli.Client().CallContext(ctx, &rawReceipt, string(GetTransactionReceipt), txHash); err != nil {
+		return nil, err
+	}
+	warnings := validateReceiptFields(rawReceipt, txHash)
+
+	// verify logsBloom of the receipts of all processed transactions, including ones with logs
+	for _, processed := range rCtx.ProcessedTransactions {
+		r, err := rCtx.EthCli.TransactionReceipt(ctx, processed)
+		if err != nil {
+			return nil, err
+		}
+		if err = verifyReceiptBloom(ctx, rCtx, r); err != nil {
+			return nil, err
+		}
+		if err = verifyCumulativeGasUsed(ctx, rCtx, r); err != nil {
+			return nil, err
+		}
+		// type of the receipt must match the type of the sent transaction,
+		// which is committed by the transaction hash
+		tx, _, err := rCtx.EthCli.TransactionByHash(ctx, processed)
+		if err != nil {
+			return nil, err
+		}
+		if tx.Hash() != processed {
+			return nil, fmt.Errorf("transaction %s is returned for hash %s", tx.Hash().Hex(), processed.Hex())
+		}
+		if r.Type != tx.Type() {
+			return nil, fmt.Errorf("receipt type of tx %s is %d, but transaction type is %d", processed.Hex(), r.Type, tx.Type())
+		}
+	}
+
+	return rCtx.RecordCustomResult(GetTransactionReceipt, utils.MustBeautifyReceipt(receipt), warnings), nil
 }
 
-func RpcSendRawTransactionDeployContract(rCtx *RpcContext) (*types.RpcResult, error) {
-	// testedRPCs is a slice of RpcResult that will be appended to rCtx.AlreadyTestedRPCs
-	// if the transaction is successfully sent
-	var testedRPCs []*types.RpcResult
-	var err error
-	// Create a new transaction
-	if rCtx.ChainId, err = rCtx.EthCli.ChainID(context.Background()); err != nil {
+// verifyReceiptBloom compares logsBloom of the receipt against the bloom computed from its logs
+// and checks the computed bloom is a subset of logsBloom of the block including the receipt
+func verifyReceiptBloom(ctx context.Context, rCtx *RpcContext, receipt *gethtypes.Receipt) error {
+	computed := gethtypes.BytesToBloom(gethtypes.LogsBloom(receipt.Logs))
+	if receipt.Bloom != computed {
+		return fmt.Errorf("receipt logsBloom of tx %s does not match computed bloom from its logs", receipt.TxHash.Hex())
+	}
+
+	blk, err := rCtx.BlockByNumber(ctx, receipt.BlockNumber.Uint64())
+	if err != nil {
+		return err
+	}
+	blockBloom := blk.Bloom()
+	for i := range computed {
+		if blockBloom[i]&computed[i] != computed[i] {
+			return fmt.Errorf("receipt logsBloom of tx %s is not a subset of block %d logsBloom", receipt.TxHash.Hex(), blk.NumberU64())
+		}
+	}
+	return nil
+}
+
+// verifyCumulativeGasUsed checks gasUsed <= cumulativeGasUsed <= gasUsed of the block,
+// and cumulativeGasUsed == gasUsed for the first transaction of the block
+func verifyCumulativeGasUsed(ctx context.Context, rCtx *RpcContext, receipt *gethtypes.Receipt) error {
+	if receipt.CumulativeGasUsed < receipt.GasUsed {
+		return fmt.Errorf("cumulativeGasUsed %d of tx %s is less than gasUsed %d", receipt.CumulativeGasUsed, receipt.TxHash.Hex(), receipt.GasUsed)
+	}
+	if receipt.TransactionIndex == 0 && receipt.CumulativeGasUsed != receipt.GasUsed {
+		return fmt.Errorf("cumulativeGasUsed %d of the first tx %s in the block differs from gasUsed %d",
+			receipt.CumulativeGasUsed, receipt.TxHash.Hex(), receipt.GasUsed)
+	}
+
+	blk, err := rCtx.BlockByNumber(ctx, receipt.BlockNumber.Uint64())
+	if err != nil {
+		return err
+	}
+	if receipt.CumulativeGasUsed > blk.GasUsed() {
+		return fmt.Errorf("cumulativeGasUsed %d of tx %s is greater than gasUsed %d of block %d",
+			receipt.CumulativeGasUsed, receipt.TxHash.Hex(), blk.GasUsed(), blk.NumberU64())
+	}
+	return nil
+}
+
+// validateReceiptFields checks that all required fields of a raw receipt are present and valid,
+// returning one warning per missing or invalid field
+func validateReceiptFields(raw map[string]interface{}, txHash common.Hash) []string {
+	var warnings []string
+	str := func(field string) (string, bool) {
+		v, ok := raw[field].(string)
+		if !ok {
+			warnings = append(warnings, fmt.Sprintf("%s is missing or not a string", field))
+		}
+		return v, ok
+	}
+
+	if v, ok := str("transactionHash"); ok && common.HexToHash(v) != txHash {
+		warnings = append(warnings, fmt.Sprintf("transactionHash %s does not match queried hash %s", v, txHash.Hex()))
+	}
+	if v, ok := str("transactionIndex"); ok {
+		if _, err := hexutil.DecodeUint64(v); err != nil {
+			warnings = append(warnings, fmt.Sprintf("transactionIndex %s is not numeric", v))
+		}
+	}
+	if v, ok := str("blockHash"); ok {
+		if b, err := hexutil.Decode(v); err != nil || len(b) != common.HashLength {
+			warnings = append(warnings, fmt.Sprintf("blockHash %s is not 32 bytes", v))
+		}
+	}
+	if v, ok := str("blockNumber"); ok {
+		if n, err := hexutil.DecodeBig(v); err != nil || n.Sign() <= 0 {
+			warnings = append(warnings, fmt.Sprintf("blockNumber %s is not positive", v))
+		}
+	}
+	if v, ok := str("from"); ok && !common.IsHexAddress(v) {
+		warnings = append(warnings, fmt.Sprintf("from %s is not a valid address", v))
+	}
+	// to is null for contract creation
+	if to, exists := raw["to"]; !exists {
+		warnings = append(warnings, "to is missing")
+	} else if to != nil {
+		if v, ok := to.(string); !ok || !common.IsHexAddress(v) {
+			warnings = append(warnings, fmt.Sprintf("to %v is not a valid address or null", to))
+		}
+	}
+	gasUsedStr, gasUsedOk := str("gasUsed")
+	cumulativeStr, cumulativeOk := str("cumulativeGasUsed")
+	if gasUsedOk && cumulativeOk {
+		gasUsed, err1 := hexutil.DecodeUint64(gasUsedStr)
+		cumulative, err2 := hexutil.DecodeUint64(cumulativeStr)
+		if err1 != nil || err2 != nil {
+			warnings = append(warnings, "gasUsed or cumulativeGasUsed is not numeric")
+		} else if gasUsed > cumulative {
+			warnings = append(warnings, fmt.Sprintf("gasUsed %d is greater than cumulativeGasUsed %d", gasUsed, cumulative))
+		}
+	}
+	if v, ok := str("status"); ok {
+		if n, err := hexutil.DecodeUint64(v); err != nil || n > 1 {
+			warnings = append(warnings, fmt.Sprintf("status %s is not 0 or 1", v))
+		}
+	}
+	if v, ok := str("logsBloom"); ok {
+		if b, err := hexutil.Decode(v); err != nil || len(b) != gethtypes.BloomByteLength {
+			warnings = append(warnings, fmt.Sprintf("logsBloom is not %d bytes", gethtypes.BloomByteLength))
+		}
+	}
+	return warnings
+}
+
+func RpcGetBlockTransactionCountByHash(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(GetBlockTransactionCountByHash); result != nil {
+		return result, nil
+	}
+
+	if len(rCtx.BlockNumsIncludingTx) == 0 {
+		return nil, errors.New("no blocks with transactions")
+	}
+
+	blkNum := rCtx.BlockNumsIncludingTx[0]
+	blk, err := rCtx.BlockByNumber(ctx, blkNum)
+	if err != nil {
 		return nil, err
 	}
-	testedRPCs = append(testedRPCs, &types.RpcResult{
-		Method: GetChainId,
-		Status: types.Ok,
-		Value:  rCtx.ChainId.String(),
-	})
 
-	nonce, err := rCtx.EthCli.PendingNonceAt(context.Background(), rCtx.Acc.Address)
+	count, err := rCtx.EthCli.TransactionCount(ctx, blk.Hash())
 	if err != nil {
 		return nil, err
 	}
-	testedRPCs = append(testedRPCs, &types.RpcResult{
-		Method: GetTransactionCount,
-		Status: types.Ok,
-		Value:  nonce,
-	})
 
-	if rCtx.MaxPriorityFeePerGas, err = rCtx.EthCli.SuggestGasTipCap(context.Background()); err != nil {
+	return rCtx.RecordCustomResult(GetBlockTransactionCountByHash, count, nil), nil
+}
+
+func RpcGetCode(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(GetCode); result != nil {
+		return result, nil
+	}
+
+	if rCtx.ERC20Addr == (common.Address{}) {
+		return nil, errors.New("no contract address, must be deployed first")
+	}
+
+	code, err := rCtx.EthCli.CodeAt(ctx, rCtx.ERC20Addr, nil)
+	if err != nil {
 		return nil, err
 	}
-	testedRPCs = append(testedRPCs, &types.RpcResult{
-		Method: GetMaxPriorityFeePerGas,
-		Status: types.Ok,
-		Value:  rCtx.MaxPriorityFeePerGas.String(),
-	})
-	if rCtx.GasPrice, err = rCtx.EthCli.SuggestGasPrice(context.Background()); err != nil {
+
+	// runtime code is a suffix of the deployment bytecode without the constructor code
+	if len(code) == 0 {
+		return nil, errors.New("on-chain code of the deployed contract is empty")
+	}
+	var warnings []string
+	if len(code) >= len(rCtx.ERC20ByteCode) {
+		warnings = append(warnings, fmt.Sprintf("on-chain code is unexpectedly large (%d bytes >= deployment bytecode %d bytes), node may store deployment bytecode",
+			len(code), len(rCtx.ERC20ByteCode)))
+	} else if !bytes.HasSuffix(rCtx.ERC20ByteCode, code) {
+		warnings = append(warnings, "on-chain code is not a suffix of the deployment bytecode")
+	}
+	if len(code) <= 100 {
+		warnings = append(warnings, fmt.Sprintf("on-chain code is too small (%d bytes) for a compiled Solidity contract", len(code)))
+	}
+	if !utils.IsRuntimeBytecode(code) {
+		warnings = append(warnings, "on-chain code does not look like runtime bytecode, node may return deployment bytecode or append constructor arguments")
+	}
+
+	var rawCode string
+	if err = rCtx.EthCli.Client().CallContext(ctx, &rawCode, string(GetCode), rCtx.ERC20Addr, "latest"); err != nil {
 		return nil, err
 	}
-	testedRPCs = append(testedRPCs, &types.RpcResult{
-		Method: GetGasPrice,
-		Status: types.Ok,
-		Value:  rCtx.GasPrice.String(),
-	})
+	if !strings.HasPrefix(rawCode, "0x") {
+		return nil, fmt.Errorf("eth_getCode returns code without 0x prefix: %s", utils.TruncateString(rawCode, 20))
+	}
 
-	tx := gethtypes.NewTx(&gethtypes.DynamicFeeTx{
-		ChainID:   rCtx.ChainId,
-		Nonce:     nonce,
-		GasTipCap: rCtx.MaxPriorityFeePerGas,
-		GasFeeCap: new(big.Int).Add(rCtx.GasPrice, big.NewInt(1000000000)),
-		Gas:       10000000,
-		Data:      common.FromHex(hex.EncodeToString(contracts.ContractByteCode)),
-	})
+	return rCtx.RecordCustomResult(GetCode, hexutils.BytesToHex(code), warnings), nil
+}
 
-	// TODO: Make signer using types.MakeSigner with chain params
-	signer := gethtypes.NewLondonSigner(rCtx.ChainId)
-	signedTx, err := gethtypes.SignTx(tx, signer, rCtx.Acc.PrivKey)
+func RpcGetCodeHistorical(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(GetCodeHistorical); result != nil {
+		return result, nil
+	}
+
+	if !rCtx.Archive {
+		// state before the deployment block may be pruned on non-archive nodes
+		return rCtx.RecordCustomResult(GetCodeHistorical, "archive mode is not enabled by -archive flag, skipped", nil), nil
+	}
+
+	if rCtx.ERC20Addr == (common.Address{}) || rCtx.ERC20DeployBlockNum == 0 {
+		return nil, errors.New("no contract address, must be deployed first")
+	}
+
+	deployBlock := new(big.Int).SetUint64(rCtx.ERC20DeployBlockNum)
+	codeBefore, err := rCtx.EthCli.CodeAt(ctx, rCtx.ERC20Addr, new(big.Int).Sub(deployBlock, big.NewInt(1)))
 	if err != nil {
 		return nil, err
 	}
+	if len(codeBefore) != 0 {
+		return nil, errors.New("eth_getCode ignores block parameter — returns code before deployment block")
+	}
 
-	if err = rCtx.EthCli.SendTransaction(context.Background(), signedTx); err != nil {
+	codeAt, err := rCtx.EthCli.CodeAt(ctx, rCtx.ERC20Addr, deployBlock)
+	if err != nil {
 		return nil, err
 	}
-	result := &types.RpcResult{
-		Method: SendRawTransaction,
-		Status: types.Ok,
-		Value:  signedTx.Hash().Hex(),
+	if len(codeAt) == 0 {
+		return nil, fmt.Errorf("eth_getCode returns empty code at deployment block %d", rCtx.ERC20DeployBlockNum)
+	}
+
+	return rCtx.RecordCustomResult(GetCodeHistorical, hexutils.BytesToHex(codeAt), nil), nil
+}
+
+func RpcGetCodePrecompiles(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(GetCodePrecompiles); result != nil {
+		return result, nil
+	}
+
+	// precompiles 0x01 ~ 0x09 have no code at the EVM level, so geth returns "0x" for them
+	var warnings []string
+	codes := make(map[string]string)
+	for i := int64(1); i <= 9; i++ {
+		addr := common.BigToAddress(big.NewInt(i))
+		code, err := rCtx.EthCli.CodeAt(ctx, addr, nil)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s: %v", addr.Hex(), err))
+			continue
+		}
+		codes[addr.Hex()] = hexutil.Encode(code)
+		if len(code) != 0 {
+			warnings = append(warnings, fmt.Sprintf("%s: code is not empty", addr.Hex()))
+		}
+	}
+
+	return rCtx.RecordCustomResult(GetCodePrecompiles, codes, warnings), nil
+}
+
+func RpcGetStorageAt(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(GetStorageAt); result != nil {
+		return result, nil
+	}
+
+	addr := rCtx.ERC20Addr
+	if rCtx.Conf.StorageAtAddress != "" {
+		addr = common.HexToAddress(rCtx.Conf.StorageAtAddress)
+	}
+	if addr == (common.Address{}) {
+		return nil, errors.New("no contract address, must be deployed first")
+	}
+
+	key := utils.MustCalculateSlotKey(rCtx.Acc.Address, rCtx.Conf.StorageAtSlotIndex)
+	storage, err := rCtx.EthCli.StorageAt(ctx, addr, key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var warnings []string
+	// check storage is zero
+	if utils.IsZeroBytes(storage) {
+		warnings = append(warnings, "storage is zero bytes, should try another slot")
+	}
+
+	// slot 0 holds the first state variable of the contract (name of ERC20), so it should be non-zero
+	slot0, err := rCtx.EthCli.StorageAt(ctx, addr, common.Hash{}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if utils.IsZeroBytes(slot0) {
+		warnings = append(warnings, "storage at slot 0 is zero bytes")
+	}
+
+	// query with the deployment block hash as block parameter and compare with the block number based query
+	if rCtx.ERC20DeployBlockNum != 0 {
+		deployBlockNum := new(big.Int).SetUint64(rCtx.ERC20DeployBlockNum)
+		header, err := rCtx.EthCli.HeaderByNumber(ctx, deployBlockNum)
+		if err != nil {
+			return nil, err
+		}
+		storageByNum, err := rCtx.EthCli.StorageAt(ctx, addr, key, deployBlockNum)
+		if err != nil {
+			return nil, err
+		}
+		var storageByHash hexutil.Bytes
+		if err = rCtx.EthCli.Client().CallContext(ctx, &storageByHash, string(GetStorageAt), addr, key, header.Hash()); err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(storageByNum, storageByHash) {
+			return nil, fmt.Errorf("storage queried by block hash (%s) differs from storage queried by block number (%s)",
+				hexutils.BytesToHex(storageByHash), hexutils.BytesToHex(storageByNum))
+		}
+	}
+
+	return rCtx.RecordCustomResult(GetStorageAt, hexutils.BytesToHex(storage), warnings), nil
+}
+
+func RpcGetStorageAtAllowance(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(GetStorageAtAllowance); result != nil {
+		return result, nil
+	}
+
+	if rCtx.ERC20Addr == (common.Address{}) {
+		return nil, errors.New("no contract address, must be deployed first")
+	}
+
+	spender := rCtx.TempAccount().Address
+	amount := new(big.Int).SetUint64(100)
+	data, err := rCtx.ERC20Abi.Pack("approve", spender, amount)
+	if err != nil {
+		log.Fatalf("Failed to pack transaction data: %v", err)
+	}
+	signedTx, err := signAndSendTx(ctx, rCtx, &rCtx.ERC20Addr, data, nil, 10000000)
+	if err != nil {
+		return nil, err
+	}
+	tout, _ := time.ParseDuration(rCtx.Conf.Timeout)
+	if err = WaitForTx(ctx, rCtx, signedTx.Hash(), tout); err != nil {
+		return nil, err
+	}
+
+	// allowance mapping is at slot 5 of the ERC20 contract
+	key := utils.MustCalculateNestedSlotKey(rCtx.Acc.Address, spender, 5)
+	storage, err := rCtx.EthCli.StorageAt(ctx, rCtx.ERC20Addr, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	if allowance := new(big.Int).SetBytes(storage); allowance.Cmp(amount) != 0 {
+		return nil, fmt.Errorf("storage of allowance slot is %s, expected approved amount %s", allowance, amount)
+	}
+
+	return rCtx.RecordCustomResult(GetStorageAtAllowance, hexutils.BytesToHex(storage), nil), nil
+}
+
+func RpcGetProof(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(GetProof); result != nil {
+		return result, nil
+	}
+
+	if rCtx.ERC20Addr == (common.Address{}) {
+		return nil, errors.New("no contract address, must be deployed first")
+	}
+
+	slot0 := common.Hash{}
+	var proof struct {
+		AccountProof []string `json:"accountProof"`
+		Balance      string   `json:"balance"`
+		Nonce        string   `json:"nonce"`
+		StorageProof []struct {
+			Key   string   `json:"key"`
+			Proof []string `json:"proof"`
+		} `json:"storageProof"`
+	}
+	if err := rCtx.EthCli.Client().CallContext(ctx, &proof, string(GetProof), rCtx.ERC20Addr, []common.Hash{slot0}, "latest"); err != nil {
+		return nil, err
+	}
+
+	if len(proof.AccountProof) == 0 {
+		return nil, errors.New("accountProof is empty")
+	}
+	if _, err := hexutil.DecodeBig(proof.Balance); err != nil {
+		return nil, fmt.Errorf("balance %s is not a hex quantity: %v", proof.Balance, err)
+	}
+	if _, err := hexutil.DecodeUint64(proof.Nonce); err != nil {
+		return nil, fmt.Errorf("nonce %s is not a hex quantity: %v", proof.Nonce, err)
+	}
+	found := false
+	for _, sp := range proof.StorageProof {
+		if common.HexToHash(sp.Key) == slot0 {
+			found = true
+		}
+	}
+	if !found {
+		return nil, errors.New("storageProof does not contain an entry for slot 0")
+	}
+
+	return rCtx.RecordCustomResult(GetProof, fmt.Sprintf("accountProof: %d nodes, balance: %s, nonce: %s",
+		len(proof.AccountProof), proof.Balance, proof.Nonce), nil), nil
+}
+
+func RpcNewFilter(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(NewFilter); result != nil {
+		return result, nil
+	}
+
+	fErc20Transfer := ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(rCtx.BlockNumsIncludingTx[0] - 1),
+		Addresses: []common.Address{rCtx.ERC20Addr},
+		Topics: [][]common.Hash{
+			{rCtx.ERC20Abi.Events["Transfer"].ID}, // Filter for Transfer event
+		},
+	}
+	args, err := utils.ToFilterArg(fErc20Transfer)
+	if err != nil {
+		return nil, err
+	}
+	var rpcId string
+	if err = rCtx.EthCli.Client().CallContext(ctx, &rpcId, string(NewFilter), args); err != nil {
+		return nil, err
+	}
+
+	result := rCtx.RecordCustomResult(NewFilter, rpcId, nil)
+	rCtx.FilterId = rpcId
+	rCtx.FilterQuery = fErc20Transfer
+
+	return result, nil
+}
+
+func RpcGetFilterLogs(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(GetFilterLogs); result != nil {
+		return result, nil
+	}
+
+	if rCtx.FilterId == "" {
+		return nil, errors.New("no filter id, must create a filter first")
 	}
-	testedRPCs = append(testedRPCs, result)
 
-	// wait for the transaction to be mined
-	tout, _ := time.ParseDuration(rCtx.Conf.Timeout)
-	if err = WaitForTx(rCtx, signedTx.Hash(), tout); err != nil {
-		return nil, err
+	if _, err := RpcSendRawTransactionTransferERC20(ctx, rCtx); err != nil {
+		return nil, errors.New("transfer ERC20 must be succeeded before checking filter logs")
 	}
 
-	if rCtx.ERC20Addr == (common.Address{}) {
-		return nil, errors.New("contract address is empty, failed to deploy")
+	var logs []gethtypes.Log
+	if err := rCtx.EthCli.Client().CallContext(ctx, &logs, string(GetFilterLogs), rCtx.FilterId); err != nil {
+		return nil, err
 	}
 
-	rCtx.AlreadyTestedRPCs = append(rCtx.AlreadyTestedRPCs, testedRPCs...)
-
-	return result, nil
+	return rCtx.RecordCustomResult(GetFilterLogs, utils.MustBeautifyLogs(logs), nil), nil
 }
 
-func RpcSendRawTransactionTransferERC20(rCtx *RpcContext) (*types.RpcResult, error) {
-	// testedRPCs is a slice of RpcResult that will be appended to rCtx.AlreadyTestedRPCs
-	// if the transaction is successfully sent
-	var testedRPCs []*types.RpcResult
-	var err error
-	// Create a new transaction
-	if rCtx.ChainId, err = rCtx.EthCli.ChainID(context.Background()); err != nil {
-		return nil, err
+func RpcNewFilterExplicitLatest(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(NewFilterExplicitLatest); result != nil {
+		return result, nil
+	}
+
+	if rCtx.FilterId == "" {
+		return nil, errors.New("no filter id, must create a filter first")
 	}
-	testedRPCs = append(testedRPCs, &types.RpcResult{
-		Method: GetChainId,
-		Status: types.Ok,
-		Value:  rCtx.ChainId.String(),
-	})
 
-	nonce, err := rCtx.EthCli.PendingNonceAt(context.Background(), rCtx.Acc.Address)
+	// install the query of RpcNewFilter twice, with toBlock omitted and with toBlock "latest" explicitly.
+	// ToFilterArg serializes a nil ToBlock as "latest", so toBlock is deleted from the omitted one.
+	omittedArgs, err := utils.ToFilterArg(rCtx.FilterQuery)
+	if err != nil {
+		return nil, err
+	}
+	delete(omittedArgs.(map[string]interface{}), "toBlock")
+	query := rCtx.FilterQuery
+	query.ToBlock = big.NewInt(int64(rpc.LatestBlockNumber))
+	explicitArgs, err := utils.ToFilterArg(query)
 	if err != nil {
 		return nil, err
 	}
-	testedRPCs = append(testedRPCs, &types.RpcResult{
-		Method: GetTransactionCount,
-		Status: types.Ok,
-		Value:  nonce,
-	})
 
-	if rCtx.MaxPriorityFeePerGas, err = rCtx.EthCli.SuggestGasTipCap(context.Background()); err != nil {
+	var omittedId, rpcId string
+	if err = rCtx.EthCli.Client().CallContext(ctx, &omittedId, string(NewFilter), omittedArgs); err != nil {
 		return nil, err
 	}
-	testedRPCs = append(testedRPCs, &types.RpcResult{
-		Method: GetMaxPriorityFeePerGas,
-		Status: types.Ok,
-		Value:  rCtx.MaxPriorityFeePerGas.String(),
-	})
-	if rCtx.GasPrice, err = rCtx.EthCli.SuggestGasPrice(context.Background()); err != nil {
+	defer func() {
+		var res bool
+		_ = rCtx.EthCli.Client().CallContext(ctx, &res, string(UninstallFilter), omittedId)
+	}()
+	if err = rCtx.EthCli.Client().CallContext(ctx, &rpcId, string(NewFilter), explicitArgs); err != nil {
 		return nil, err
 	}
-	testedRPCs = append(testedRPCs, &types.RpcResult{
-		Method: GetGasPrice,
-		Status: types.Ok,
-		Value:  rCtx.GasPrice.String(),
-	})
+	defer func() {
+		var res bool
+		_ = rCtx.EthCli.Client().CallContext(ctx, &res, string(UninstallFilter), rpcId)
+	}()
 
-	randomRecipient := utils.MustCreateRandomAccount().Address
-	data, err := rCtx.ERC20Abi.Pack("transfer", randomRecipient, new(big.Int).SetUint64(1))
-	if err != nil {
-		log.Fatalf("Failed to pack transaction data: %v", err)
+	var logs, explicitLogs []gethtypes.Log
+	if err = rCtx.EthCli.Client().CallContext(ctx, &logs, string(GetFilterLogs), omittedId); err != nil {
+		return nil, err
 	}
-
-	// Erc20 transfer
-	tx := gethtypes.NewTx(&gethtypes.DynamicFeeTx{
-		ChainID:   rCtx.ChainId,
-		Nonce:     nonce,
-		GasTipCap: rCtx.MaxPriorityFeePerGas,
-		GasFeeCap: new(big.Int).Add(rCtx.GasPrice, big.NewInt(1000000000)),
-		Gas:       10000000,
-		To:        &rCtx.ERC20Addr,
-		Data:      data,
-	})
-
-	// TODO: Make signer using types.MakeSigner with chain params
-	signer := gethtypes.NewLondonSigner(rCtx.ChainId)
-	signedTx, err := gethtypes.SignTx(tx, signer, rCtx.Acc.PrivKey)
-	if err != nil {
+	if err = rCtx.EthCli.Client().CallContext(ctx, &explicitLogs, string(GetFilterLogs), rpcId); err != nil {
 		return nil, err
 	}
 
-	if err = rCtx.EthCli.SendTransaction(context.Background(), signedTx); err != nil {
-		return nil, err
+	if len(logs) != len(explicitLogs) {
+		return nil, fmt.Errorf("filter with explicit latest toBlock returns %d logs, but filter without toBlock returns %d logs", len(explicitLogs), len(logs))
+	}
+	for i := range logs {
+		if logs[i].TxHash != explicitLogs[i].TxHash || logs[i].Index != explicitLogs[i].Index {
+			return nil, fmt.Errorf("log %d of filter with explicit latest toBlock differs from filter without toBlock", i)
+		}
 	}
 
-	result := &types.RpcResult{
-		Method: SendRawTransaction,
-		Status: types.Ok,
-		Value:  signedTx.Hash().Hex(),
+	return rCtx.RecordCustomResult(NewFilterExplicitLatest, rpcId, nil), nil
+}
+
+func RpcNewBlockFilter(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(NewBlockFilter); result != nil {
+		return result, nil
 	}
-	testedRPCs = append(testedRPCs, result)
 
-	// wait for the transaction to be mined
-	tout, _ := time.ParseDuration(rCtx.Conf.Timeout)
-	if err = WaitForTx(rCtx, signedTx.Hash(), tout); err != nil {
+	var rpcId string
+	if err := rCtx.EthCli.Client().CallContext(ctx, &rpcId, string(NewBlockFilter)); err != nil {
 		return nil, err
 	}
 
-	rCtx.AlreadyTestedRPCs = append(rCtx.AlreadyTestedRPCs, testedRPCs...)
+	result := rCtx.RecordCustomResult(NewBlockFilter, rpcId, nil)
+	rCtx.BlockFilterId = rpcId
 
 	return result, nil
 }
 
-func RpcGetBlockReceipts(rCtx *RpcContext) (*types.RpcResult, error) {
-	if result := rCtx.AlreadyTested(GetBlockReceipts); result != nil {
+func RpcGetFilterChanges(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(GetFilterChanges); result != nil {
 		return result, nil
 	}
 
-	if len(rCtx.BlockNumsIncludingTx) == 0 {
-		return nil, errors.New("no blocks with transactions")
-
+	if rCtx.BlockFilterId == "" {
+		return nil, errors.New("no block filter id, must create a block filter first")
 	}
 
-	// TODO: Random pick
-	// pick a block with transactions
-	blkNum := rCtx.BlockNumsIncludingTx[0]
-	rpcBlockNum := rpc.BlockNumber(blkNum)
-	receipts, err := rCtx.EthCli.BlockReceipts(context.Background(), rpc.BlockNumberOrHash{BlockNumber: &rpcBlockNum})
-	if err != nil {
+	// TODO: Make it configurable
+	time.Sleep(3 * time.Second) // wait for a new block to be mined
+
+	var changes []interface{}
+	if err := rCtx.EthCli.Client().CallContext(ctx, &changes, string(GetFilterChanges), rCtx.BlockFilterId); err != nil {
 		return nil, err
 	}
 
-	result := &types.RpcResult{
-		Method: GetBlockReceipts,
-		Status: types.Ok,
-		Value:  utils.MustBeautifyReceipts(receipts),
+	warnings := []string{}
+	if len(changes) == 0 {
+		warnings = append(warnings, "no new blocks")
 	}
-	rCtx.AlreadyTestedRPCs = append(rCtx.AlreadyTestedRPCs, result)
 
-	return result, nil
+	// the second call without new blocks should not return the block hashes consumed by the first call
+	var secondChanges []interface{}
+	if err := rCtx.EthCli.Client().CallContext(ctx, &secondChanges, string(GetFilterChanges), rCtx.BlockFilterId); err != nil {
+		return nil, err
+	}
+	consumed := make(map[string]bool)
+	for _, c := range changes {
+		consumed[fmt.Sprint(c)] = true
+	}
+	for _, c := range secondChanges {
+		if consumed[fmt.Sprint(c)] {
+			return nil, errors.New("eth_getFilterChanges is not consuming filter state — duplicate results returned")
+		}
+	}
+
+	return rCtx.RecordCustomResult(GetFilterChanges, changes, warnings), nil
 }
 
-func RpcGetTransactionByHash(rCtx *RpcContext) (*types.RpcResult, error) {
-	if result := rCtx.AlreadyTested(GetTransactionByHash); result != nil {
+func RpcUninstallFilter(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(UninstallFilter); result != nil {
 		return result, nil
 	}
 
-	if len(rCtx.ProcessedTransactions) == 0 {
-		return nil, errors.New("no transactions")
+	if rCtx.FilterId == "" {
+		return nil, errors.New("no filter id, must create a filter first")
 	}
 
-	// TODO: Random pick
-	txHash := rCtx.ProcessedTransactions[0]
-	tx, _, err := rCtx.EthCli.TransactionByHash(context.Background(), txHash)
-	if err != nil {
+	var res bool
+	if err := rCtx.EthCli.Client().CallContext(ctx, &res, string(UninstallFilter), rCtx.FilterId); err != nil {
 		return nil, err
 	}
+	if !res {
+		return nil, errors.New("uninstall filter failed")
+	}
 
-	result := &types.RpcResult{
-		Method: GetTransactionByHash,
-		Status: types.Ok,
-		Value:  utils.MustBeautifyTransaction(tx),
+	if err := rCtx.EthCli.Client().CallContext(ctx, &res, string(UninstallFilter), rCtx.FilterId); err != nil {
+		return nil, err
+	}
+	if res {
+		return nil, errors.New("uninstall filter should be failed because it was already uninstalled")
 	}
-	rCtx.AlreadyTestedRPCs = append(rCtx.AlreadyTestedRPCs, result)
 
-	return result, nil
+	return rCtx.RecordCustomResult(UninstallFilter, rCtx.FilterId, nil), nil
 }
 
-func RpcGetTransactionByBlockHashAndIndex(rCtx *RpcContext) (*types.RpcResult, error) {
-	if result := rCtx.AlreadyTested(GetTransactionByBlockHashAndIndex); result != nil {
+func RpcGetLogs(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(GetLogs); result != nil {
 		return result, nil
 	}
 
-	if len(rCtx.BlockNumsIncludingTx) == 0 {
-		return nil, errors.New("no blocks with transactions")
-	}
-
-	// TODO: Random pick
-	blkNum := rCtx.BlockNumsIncludingTx[0]
-	blk, err := rCtx.EthCli.BlockByNumber(context.Background(), new(big.Int).SetUint64(blkNum))
-	if err != nil {
-		return nil, err
+	if _, err := RpcNewFilter(ctx, rCtx); err != nil {
+		return nil, errors.New("failed to create a filter")
 	}
 
-	if len(blk.Transactions()) == 0 {
-		return nil, errors.New("no transactions in the block")
+	if _, err := RpcSendRawTransactionTransferERC20(ctx, rCtx); err != nil {
+		return nil, errors.New("transfer ERC20 must be succeeded before checking filter logs")
 	}
 
-	tx, err := rCtx.EthCli.TransactionInBlock(context.Background(), blk.Hash(), 0)
+	// set from block because of limit
+	logs, err := rCtx.EthCli.FilterLogs(ctx, rCtx.FilterQuery)
 	if err != nil {
 		return nil, err
 	}
 
-	result := &types.RpcResult{
-		Method: GetTransactionByBlockHashAndIndex,
-		Status: types.Ok,
-		Value:  utils.MustBeautifyTransaction(tx),
+	warnings := []string{}
+	if len(logs) == 0 {
+		warnings = append(warnings, "no logs")
 	}
-	rCtx.AlreadyTestedRPCs = append(rCtx.AlreadyTestedRPCs, result)
 
-	return result, nil
+	return rCtx.RecordCustomResult(GetLogs, utils.MustBeautifyLogs(logs), warnings), nil
 }
 
-func RpcGetTransactionByBlockNumberAndIndex(rCtx *RpcContext) (*types.RpcResult, error) {
-	if result := rCtx.AlreadyTested(GetTransactionByBlockNumberAndIndex); result != nil {
+func RpcGetLogsBlockHashConflict(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(GetLogsBlockHashConflict); result != nil {
 		return result, nil
 	}
 
@@ -725,357 +2786,535 @@ func RpcGetTransactionByBlockNumberAndIndex(rCtx *RpcContext) (*types.RpcResult,
 		return nil, errors.New("no blocks with transactions")
 	}
 
-	// TODO: Random pick
-	blkNum := rCtx.BlockNumsIncludingTx[0]
-	var tx gethtypes.Transaction
-	if err := rCtx.EthCli.Client().CallContext(context.Background(), &tx, string(GetTransactionByBlockNumberAndIndex), blkNum, "0x0"); err != nil {
+	blkNum := new(big.Int).SetUint64(rCtx.BlockNumsIncludingTx[0])
+	header, err := rCtx.EthCli.HeaderByNumber(ctx, blkNum)
+	if err != nil {
 		return nil, err
 	}
 
-	result := &types.RpcResult{
-		Method: GetTransactionByBlockNumberAndIndex,
-		Status: types.Ok,
-		Value:  utils.MustBeautifyTransaction(&tx),
+	// utils.ToFilterArg rejects this combination, so the argument is built manually
+	arg := map[string]interface{}{
+		"blockHash": header.Hash(),
+		"fromBlock": hexutil.EncodeBig(blkNum),
+		"toBlock":   hexutil.EncodeBig(blkNum),
+	}
+	var logs []gethtypes.Log
+	err = rCtx.EthCli.Client().CallContext(ctx, &logs, string(GetLogs), arg)
+
+	if err != nil {
+		// the node rejects blockHash with fromBlock/toBlock as the spec requires
+		return rCtx.RecordCustomResult(GetLogsBlockHashConflict, err.Error(), nil), nil
 	}
-	rCtx.AlreadyTestedRPCs = append(rCtx.AlreadyTestedRPCs, result)
+	warnings := []string{"node accepts blockHash with fromBlock/toBlock, which violates the spec"}
 
-	return result, nil
+	return rCtx.RecordCustomResult(GetLogsBlockHashConflict, utils.MustBeautifyLogs(logs), warnings), nil
 }
 
-func RpcGetTransactionCountByHash(rCtx *RpcContext) (*types.RpcResult, error) {
-	if result := rCtx.AlreadyTested(GetTransactionCountByHash); result != nil {
+func RpcGetLogsByBlockHashAndAddress(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(GetLogsByBlockHashAndAddress); result != nil {
 		return result, nil
 	}
 
-	if len(rCtx.BlockNumsIncludingTx) == 0 {
-		return nil, errors.New("no transactions")
+	if rCtx.ERC20Addr == (common.Address{}) {
+		return nil, errors.New("no contract address, must be deployed first")
 	}
 
-	// get block
-	blkNum := rCtx.BlockNumsIncludingTx[0]
-	blk, err := rCtx.EthCli.BlockByNumber(context.Background(), new(big.Int).SetUint64(blkNum))
+	// find a block including logs of the ERC20 contract by block range query
+	var rangeLogs []gethtypes.Log
+	var blkNum *big.Int
+	for _, num := range rCtx.BlockNumsIncludingTx {
+		blkNum = new(big.Int).SetUint64(num)
+		logs, err := rCtx.EthCli.FilterLogs(ctx, ethereum.FilterQuery{
+			FromBlock: blkNum,
+			ToBlock:   blkNum,
+			Addresses: []common.Address{rCtx.ERC20Addr},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(logs) > 0 {
+			rangeLogs = logs
+			break
+		}
+	}
+	if len(rangeLogs) == 0 {
+		return nil, errors.New("no blocks with logs of the ERC20 contract")
+	}
+
+	blockHash := rangeLogs[0].BlockHash
+	hashLogs, err := rCtx.EthCli.FilterLogs(ctx, ethereum.FilterQuery{
+		BlockHash: &blockHash,
+		Addresses: []common.Address{rCtx.ERC20Addr},
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	var count uint64
-	if err = rCtx.EthCli.Client().CallContext(context.Background(), &count, string(GetTransactionCountByHash), blk.Hash()); err != nil {
-		return nil, err
+	// logs by block hash must be a subset of logs by block range of the same block
+	inRange := make(map[string]bool)
+	for _, l := range rangeLogs {
+		inRange[fmt.Sprintf("%s-%d", l.TxHash.Hex(), l.Index)] = true
+	}
+	for _, l := range hashLogs {
+		if l.Address != rCtx.ERC20Addr {
+			return nil, fmt.Errorf("log %d of tx %s is not emitted by the filtered address %s", l.Index, l.TxHash.Hex(), rCtx.ERC20Addr.Hex())
+		}
+		if !inRange[fmt.Sprintf("%s-%d", l.TxHash.Hex(), l.Index)] {
+			return nil, fmt.Errorf("log %d of tx %s queried by blockHash is not found by block range query of block %s",
+				l.Index, l.TxHash.Hex(), blkNum)
+		}
 	}
 
-	result := &types.RpcResult{
-		Method: GetTransactionCountByHash,
-		Status: types.Ok,
-		Value:  count,
+	var warnings []string
+	if len(hashLogs) == 0 {
+		warnings = append(warnings, fmt.Sprintf("no logs by blockHash %s, but %d logs by block range", blockHash.Hex(), len(rangeLogs)))
 	}
-	rCtx.AlreadyTestedRPCs = append(rCtx.AlreadyTestedRPCs, result)
 
-	return result, nil
+	return rCtx.RecordCustomResult(GetLogsByBlockHashAndAddress, utils.MustBeautifyLogs(hashLogs), warnings), nil
 }
 
-func RpcGetTransactionReceipt(rCtx *RpcContext) (*types.RpcResult, error) {
-	if result := rCtx.AlreadyTested(GetTransactionReceipt); result != nil {
+func RpcGetLogsMultiEvent(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(GetLogsMultiEvent); result != nil {
 		return result, nil
 	}
 
-	if len(rCtx.ProcessedTransactions) == 0 {
-		return nil, errors.New("no transactions")
+	if rCtx.ERC20Addr == (common.Address{}) {
+		return nil, errors.New("no contract address, must be deployed first")
 	}
 
-	txHash := rCtx.ProcessedTransactions[0]
-	receipt, err := rCtx.EthCli.TransactionReceipt(context.Background(), txHash)
+	eventIds := []common.Hash{rCtx.ERC20Abi.Events["Transfer"].ID}
+	approval, hasApproval := rCtx.ERC20Abi.Events["Approval"]
+	if hasApproval {
+		eventIds = append(eventIds, approval.ID)
+
+		// emit an Approval event, Transfer events are already emitted by previous transactions
+		data, err := rCtx.ERC20Abi.Pack("approve", rCtx.TempAccount().Address, new(big.Int).SetUint64(1))
+		if err != nil {
+			log.Fatalf("Failed to pack transaction data: %v", err)
+		}
+		signedTx, err := signAndSendTx(ctx, rCtx, &rCtx.ERC20Addr, data, nil, 10000000)
+		if err != nil {
+			return nil, err
+		}
+		tout, _ := time.ParseDuration(rCtx.Conf.Timeout)
+		if err = WaitForTx(ctx, rCtx, signedTx.Hash(), tout); err != nil {
+			return nil, err
+		}
+	}
+
+	// topics[0] with multiple event ids matches any of them
+	logs, err := rCtx.EthCli.FilterLogs(ctx, ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(rCtx.ERC20DeployBlockNum),
+		Addresses: []common.Address{rCtx.ERC20Addr},
+		Topics:    [][]common.Hash{eventIds},
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	result := &types.RpcResult{
-		Method: GetTransactionReceipt,
-		Status: types.Ok,
-		Value:  utils.MustBeautifyReceipt(receipt),
+	found := make(map[common.Hash]bool)
+	for _, l := range logs {
+		if len(l.Topics) == 0 {
+			return nil, fmt.Errorf("log %d of tx %s has no topics", l.Index, l.TxHash.Hex())
+		}
+		matched := false
+		for _, id := range eventIds {
+			if l.Topics[0] == id {
+				matched = true
+				found[id] = true
+			}
+		}
+		if !matched {
+			return nil, fmt.Errorf("topics[0] %s of log %d does not match any of the requested events", l.Topics[0].Hex(), l.Index)
+		}
 	}
-	rCtx.AlreadyTestedRPCs = append(rCtx.AlreadyTestedRPCs, result)
 
-	return result, nil
+	var warnings []string
+	if !hasApproval {
+		warnings = append(warnings, "Approval event is not found in the ERC20 ABI, only Transfer is queried")
+	}
+	for _, id := range eventIds {
+		if !found[id] {
+			warnings = append(warnings, fmt.Sprintf("no logs for event %s", id.Hex()))
+		}
+	}
+
+	return rCtx.RecordCustomResult(GetLogsMultiEvent, utils.MustBeautifyLogs(logs), warnings), nil
 }
 
-func RpcGetBlockTransactionCountByHash(rCtx *RpcContext) (*types.RpcResult, error) {
-	if result := rCtx.AlreadyTested(GetBlockTransactionCountByHash); result != nil {
+func RpcEstimateGas(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(EstimateGas); result != nil {
 		return result, nil
 	}
 
-	if len(rCtx.BlockNumsIncludingTx) == 0 {
-		return nil, errors.New("no blocks with transactions")
+	if rCtx.ERC20Addr == (common.Address{}) {
+		return nil, errors.New("no contract address, must be deployed first")
 	}
 
-	blkNum := rCtx.BlockNumsIncludingTx[0]
-	blk, err := rCtx.EthCli.BlockByNumber(context.Background(), new(big.Int).SetUint64(blkNum))
+	data, err := rCtx.ERC20Abi.Pack("transfer", rCtx.Acc.Address, new(big.Int).SetUint64(1))
+	if err != nil {
+		log.Fatalf("Failed to pack transaction data: %v", err)
+	}
+
+	msg := ethereum.CallMsg{
+		From: rCtx.Acc.Address,
+		To:   &rCtx.ERC20Addr,
+		Data: data,
+	}
+	gas, err := rCtx.EthCli.EstimateGas(ctx, msg)
 	if err != nil {
 		return nil, err
 	}
 
-	count, err := rCtx.EthCli.TransactionCount(context.Background(), blk.Hash())
+	return rCtx.RecordCustomResult(EstimateGas, gas, nil), nil
+}
+
+func RpcCreateAccessList(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(CreateAccessList); result != nil {
+		return result, nil
+	}
+
+	if rCtx.ERC20Addr == (common.Address{}) {
+		return nil, errors.New("no contract address, must be deployed first")
+	}
+
+	// same call as eth_estimateGas
+	data, err := rCtx.ERC20Abi.Pack("transfer", rCtx.Acc.Address, new(big.Int).SetUint64(1))
 	if err != nil {
+		log.Fatalf("Failed to pack transaction data: %v", err)
+	}
+	arg := map[string]interface{}{
+		"from": rCtx.Acc.Address,
+		"to":   rCtx.ERC20Addr,
+		"data": hexutil.Bytes(data),
+	}
+	var res struct {
+		AccessList *gethtypes.AccessList `json:"accessList"`
+		GasUsed    hexutil.Uint64        `json:"gasUsed"`
+		Error      string                `json:"error,omitempty"`
+	}
+	if err = rCtx.EthCli.Client().CallContext(ctx, &res, string(CreateAccessList), arg, "latest"); err != nil {
 		return nil, err
 	}
+	if res.Error != "" {
+		return nil, fmt.Errorf("eth_createAccessList returns error: %s", res.Error)
+	}
+	if res.AccessList == nil {
+		return nil, errors.New("accessList is null")
+	}
+	found := false
+	for _, tuple := range *res.AccessList {
+		if tuple.Address == rCtx.ERC20Addr {
+			found = true
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("accessList does not contain the ERC20 contract %s", rCtx.ERC20Addr.Hex())
+	}
 
-	result := &types.RpcResult{
-		Method: GetBlockTransactionCountByHash,
-		Status: types.Ok,
-		Value:  count,
+	// the mined ERC20 transfer to a new recipient costs more than the transfer to the sender itself
+	transferMethod := rCtx.ERC20Abi.Methods["transfer"]
+	for _, txHash := range rCtx.ProcessedTransactions {
+		tx, _, err := rCtx.EthCli.TransactionByHash(ctx, txHash)
+		if err != nil {
+			return nil, err
+		}
+		if tx.To() == nil || *tx.To() != rCtx.ERC20Addr || !bytes.HasPrefix(tx.Data(), transferMethod.ID) {
+			continue
+		}
+		receipt, err := rCtx.EthCli.TransactionReceipt(ctx, txHash)
+		if err != nil {
+			return nil, err
+		}
+		if uint64(res.GasUsed) > receipt.GasUsed {
+			return nil, fmt.Errorf("gasUsed of eth_createAccessList (%d) is greater than gasUsed of the mined ERC20 transfer (%d)",
+				uint64(res.GasUsed), receipt.GasUsed)
+		}
+		break
 	}
-	rCtx.AlreadyTestedRPCs = append(rCtx.AlreadyTestedRPCs, result)
 
-	return result, nil
+	return rCtx.RecordCustomResult(CreateAccessList, res.AccessList, nil), nil
 }
 
-func RpcGetCode(rCtx *RpcContext) (*types.RpcResult, error) {
-	if result := rCtx.AlreadyTested(GetCode); result != nil {
+func RpcEstimateGasWithAccessListComparison(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(EstimateGasAccessListComparison); result != nil {
 		return result, nil
 	}
 
-	if rCtx.ERC20Addr == (common.Address{}) {
-		return nil, errors.New("no contract address, must be deployed first")
+	estimated, err := RpcEstimateGas(ctx, rCtx)
+	if err != nil {
+		return nil, errors.New("eth_estimateGas must be succeeded before comparing gas with access list")
+	}
+
+	data, err := rCtx.ERC20Abi.Pack("transfer", rCtx.Acc.Address, new(big.Int).SetUint64(1))
+	if err != nil {
+		log.Fatalf("Failed to pack transaction data: %v", err)
 	}
 
-	code, err := rCtx.EthCli.CodeAt(context.Background(), rCtx.ERC20Addr, nil)
+	accessList, err := RpcCreateAccessList(ctx, rCtx)
 	if err != nil {
+		return nil, errors.New("eth_createAccessList must be succeeded before comparing gas with access list")
+	}
+
+	arg := map[string]interface{}{
+		"from":       rCtx.Acc.Address,
+		"to":         rCtx.ERC20Addr,
+		"data":       hexutil.Bytes(data),
+		"accessList": accessList.Value,
+	}
+	var gasWithAccessList hexutil.Uint64
+	if err = rCtx.EthCli.Client().CallContext(ctx, &gasWithAccessList, string(EstimateGas), arg); err != nil {
 		return nil, err
 	}
 
-	result := &types.RpcResult{
-		Method: GetCode,
-		Status: types.Ok,
-		Value:  hexutils.BytesToHex(code),
+	// access list benefit is the gas saved by warming up the accessed addresses and storage slots
+	benefit := int64(estimated.Value.(uint64)) - int64(gasWithAccessList)
+	var warnings []string
+	if benefit == 0 {
+		warnings = append(warnings, "access list has no effect on estimated gas")
 	}
-	rCtx.AlreadyTestedRPCs = append(rCtx.AlreadyTestedRPCs, result)
 
-	return result, nil
+	return rCtx.RecordCustomResult(EstimateGasAccessListComparison, benefit, warnings), nil
 }
 
-func RpcGetStorageAt(rCtx *RpcContext) (*types.RpcResult, error) {
-	if result := rCtx.AlreadyTested(GetStorageAt); result != nil {
+func RpcEstimateGasNoCap(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(EstimateGasNoCap); result != nil {
 		return result, nil
 	}
 
-	if rCtx.ERC20Addr == (common.Address{}) {
-		return nil, errors.New("no contract address, must be deployed first")
+	estimated, err := RpcEstimateGas(ctx, rCtx)
+	if err != nil {
+		return nil, errors.New("eth_estimateGas must be succeeded before checking estimation without gas cap")
 	}
 
-	key := utils.MustCalculateSlotKey(rCtx.Acc.Address, 4)
-	storage, err := rCtx.EthCli.StorageAt(context.Background(), rCtx.ERC20Addr, key, nil)
+	data, err := rCtx.ERC20Abi.Pack("transfer", rCtx.Acc.Address, new(big.Int).SetUint64(1))
 	if err != nil {
-		return nil, err
+		log.Fatalf("Failed to pack transaction data: %v", err)
 	}
 
-	var warnings []string
-	status := types.Ok
-	// check storage is zero
-	if utils.IsZeroBytes(storage) {
-		warnings = append(warnings, "storage is zero bytes, should try another slot")
-		status = types.Warning
+	// ethclient omits a zero gas field, so the call object is built manually to send it explicitly
+	arg := map[string]interface{}{
+		"from": rCtx.Acc.Address,
+		"to":   rCtx.ERC20Addr,
+		"data": hexutil.Bytes(data),
+		"gas":  hexutil.Uint64(0),
+	}
+	var gas hexutil.Uint64
+	err = rCtx.EthCli.Client().CallContext(ctx, &gas, string(EstimateGas), arg)
+	if err != nil {
+		if !strings.Contains(err.Error(), "intrinsic gas too low") {
+			return nil, err
+		}
+		return rCtx.RecordCustomResult(EstimateGasNoCap, err.Error(), []string{"node requires an explicit gas value for eth_estimateGas"}), nil
 	}
 
-	result := &types.RpcResult{
-		Method:   GetStorageAt,
-		Status:   status,
-		Value:    hexutils.BytesToHex(storage),
-		Warnings: warnings,
+	if uint64(gas) != estimated.Value.(uint64) {
+		return nil, fmt.Errorf("estimation with gas 0 (%d) differs from estimation without gas field (%d)", uint64(gas), estimated.Value.(uint64))
 	}
-	rCtx.AlreadyTestedRPCs = append(rCtx.AlreadyTestedRPCs, result)
 
-	return result, nil
+	return rCtx.RecordCustomResult(EstimateGasNoCap, uint64(gas), nil), nil
 }
 
-func RpcNewFilter(rCtx *RpcContext) (*types.RpcResult, error) {
-	if result := rCtx.AlreadyTested(NewFilter); result != nil {
+func RpcEstimateGasDeployment(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(EstimateGasDeployment); result != nil {
 		return result, nil
 	}
 
-	fErc20Transfer := ethereum.FilterQuery{
-		FromBlock: new(big.Int).SetUint64(rCtx.BlockNumsIncludingTx[0] - 1),
-		Addresses: []common.Address{rCtx.ERC20Addr},
-		Topics: [][]common.Hash{
-			{rCtx.ERC20Abi.Events["Transfer"].ID}, // Filter for Transfer event
-		},
+	if rCtx.ERC20DeployReceipt == nil {
+		return nil, errors.New("no deployment receipt, must be deployed first")
 	}
-	args, err := utils.ToFilterArg(fErc20Transfer)
+
+	header, err := rCtx.EthCli.HeaderByNumber(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
-	var rpcId string
-	if err = rCtx.EthCli.Client().CallContext(context.Background(), &rpcId, string(NewFilter), args); err != nil {
+
+	msg := ethereum.CallMsg{
+		From: rCtx.Acc.Address,
+		Data: rCtx.ERC20ByteCode,
+	}
+	gas, err := rCtx.EthCli.EstimateGas(ctx, msg)
+	if err != nil {
 		return nil, err
 	}
 
-	result := &types.RpcResult{
-		Method: NewFilter,
-		Status: types.Ok,
-		Value:  rpcId,
+	if gas <= params.TxGas {
+		return nil, fmt.Errorf("estimated deployment gas %d is not greater than %d", gas, params.TxGas)
+	}
+	if gas >= header.GasLimit {
+		return nil, fmt.Errorf("estimated deployment gas %d is not less than block gas limit %d", gas, header.GasLimit)
 	}
-	rCtx.AlreadyTestedRPCs = append(rCtx.AlreadyTestedRPCs, result)
-	rCtx.FilterId = rpcId
-	rCtx.FilterQuery = fErc20Transfer
 
-	return result, nil
+	// compare with the gas used by the actual deployment
+	var warnings []string
+	gasUsed := rCtx.ERC20DeployReceipt.GasUsed
+	diff := new(big.Int).Abs(new(big.Int).Sub(new(big.Int).SetUint64(gas), new(big.Int).SetUint64(gasUsed)))
+	if new(big.Int).Mul(diff, big.NewInt(5)).Cmp(new(big.Int).SetUint64(gasUsed)) > 0 {
+		warnings = append(warnings, fmt.Sprintf("estimated gas %d is off by more than 20%% from actual gas used %d", gas, gasUsed))
+	}
+
+	return rCtx.RecordCustomResult(EstimateGasDeployment, gas, warnings), nil
 }
 
-func RpcGetFilterLogs(rCtx *RpcContext) (*types.RpcResult, error) {
-	if result := rCtx.AlreadyTested(GetFilterLogs); result != nil {
+func RPCCall(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(Call); result != nil {
 		return result, nil
 	}
 
-	if rCtx.FilterId == "" {
-		return nil, errors.New("no filter id, must create a filter first")
+	if rCtx.ERC20Addr == (common.Address{}) {
+		return nil, errors.New("no contract address, must be deployed first")
 	}
 
-	if _, err := RpcSendRawTransactionTransferERC20(rCtx); err != nil {
-		return nil, errors.New("transfer ERC20 must be succeeded before checking filter logs")
+	data, err := rCtx.ERC20Abi.Pack("balanceOf", rCtx.Acc.Address)
+	if err != nil {
+		log.Fatalf("Failed to pack transaction data: %v", err)
 	}
 
-	var logs []gethtypes.Log
-	if err := rCtx.EthCli.Client().CallContext(context.Background(), &logs, string(GetFilterLogs), rCtx.FilterId); err != nil {
+	msg := ethereum.CallMsg{
+		From: rCtx.Acc.Address,
+		To:   &rCtx.ERC20Addr,
+		Data: data,
+	}
+	res, err := rCtx.EthCli.CallContract(ctx, msg, nil)
+	if err != nil {
 		return nil, err
 	}
 
-	result := &types.RpcResult{
-		Method: GetFilterLogs,
-		Status: types.Ok,
-		Value:  utils.MustBeautifyLogs(logs),
+	// balanceOf does not depend on msg.sender, so the call without a sender should return the same result
+	msg.From = common.Address{}
+	resZeroFrom, err := rCtx.EthCli.CallContract(ctx, msg, nil)
+	if err != nil {
+		return nil, err
+	}
+	var warnings []string
+	if !bytes.Equal(res, resZeroFrom) {
+		warnings = append(warnings, "eth_call with zero from address returns different result")
 	}
-	rCtx.AlreadyTestedRPCs = append(rCtx.AlreadyTestedRPCs, result)
 
-	return result, nil
+	return rCtx.RecordCustomResult(Call, hexutils.BytesToHex(res), warnings), nil
 }
 
-func RpcNewBlockFilter(rCtx *RpcContext) (*types.RpcResult, error) {
-	if result := rCtx.AlreadyTested(NewBlockFilter); result != nil {
+func RpcCallWithGasPrice(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(CallWithGasPrice); result != nil {
 		return result, nil
 	}
 
-	var rpcId string
-	if err := rCtx.EthCli.Client().CallContext(context.Background(), &rpcId, string(NewBlockFilter)); err != nil {
-		return nil, err
+	callResult, err := RPCCall(ctx, rCtx)
+	if err != nil {
+		return nil, errors.New("eth_call must be succeeded before checking eth_call with gasPrice")
 	}
 
-	result := &types.RpcResult{
-		Method: NewBlockFilter,
-		Status: types.Ok,
-		Value:  rpcId,
+	gasPrice := rCtx.GasPrice
+	if gasPrice == nil {
+		if gasPrice, err = rCtx.EthCli.SuggestGasPrice(ctx); err != nil {
+			return nil, err
+		}
 	}
-	rCtx.AlreadyTestedRPCs = append(rCtx.AlreadyTestedRPCs, result)
-	rCtx.BlockFilterId = rpcId
 
-	return result, nil
-}
-
-func RpcGetFilterChanges(rCtx *RpcContext) (*types.RpcResult, error) {
-	if result := rCtx.AlreadyTested(GetFilterChanges); result != nil {
-		return result, nil
+	data, err := rCtx.ERC20Abi.Pack("balanceOf", rCtx.Acc.Address)
+	if err != nil {
+		log.Fatalf("Failed to pack transaction data: %v", err)
 	}
 
-	if rCtx.BlockFilterId == "" {
-		return nil, errors.New("no block filter id, must create a block filter first")
+	// legacy gas pricing should not change the result of the call
+	msg := ethereum.CallMsg{
+		From:     rCtx.Acc.Address,
+		To:       &rCtx.ERC20Addr,
+		GasPrice: gasPrice,
+		Data:     data,
 	}
-
-	// TODO: Make it configurable
-	time.Sleep(3 * time.Second) // wait for a new block to be mined
-
-	var changes []interface{}
-	if err := rCtx.EthCli.Client().CallContext(context.Background(), &changes, string(GetFilterChanges), rCtx.BlockFilterId); err != nil {
+	res, err := rCtx.EthCli.CallContract(ctx, msg, nil)
+	if err != nil {
 		return nil, err
 	}
 
-	status := types.Ok
-	warnings := []string{}
-	if len(changes) == 0 {
-		status = types.Warning
-		warnings = append(warnings, "no new blocks")
-	}
-
-	result := &types.RpcResult{
-		Method:   GetFilterChanges,
-		Status:   status,
-		Value:    changes,
-		Warnings: warnings,
+	value := hexutils.BytesToHex(res)
+	var warnings []string
+	if value != callResult.Value {
+		warnings = append(warnings, fmt.Sprintf("eth_call with gasPrice returns different result: %s != %s", value, callResult.Value))
 	}
-	rCtx.AlreadyTestedRPCs = append(rCtx.AlreadyTestedRPCs, result)
 
-	return result, nil
+	return rCtx.RecordCustomResult(CallWithGasPrice, value, warnings), nil
 }
 
-func RpcUninstallFilter(rCtx *RpcContext) (*types.RpcResult, error) {
-	if result := rCtx.AlreadyTested(UninstallFilter); result != nil {
+func RpcCallContractCreation(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(CallContractCreation); result != nil {
 		return result, nil
 	}
 
-	if rCtx.FilterId == "" {
-		return nil, errors.New("no filter id, must create a filter first")
-	}
-
-	var res bool
-	if err := rCtx.EthCli.Client().CallContext(context.Background(), &res, string(UninstallFilter), rCtx.FilterId); err != nil {
-		return nil, err
-	}
-	if !res {
-		return nil, errors.New("uninstall filter failed")
+	// eth_call without a recipient simulates a contract deployment and returns the runtime bytecode
+	msg := ethereum.CallMsg{
+		From: rCtx.Acc.Address,
+		Data: rCtx.ERC20ByteCode,
 	}
-
-	if err := rCtx.EthCli.Client().CallContext(context.Background(), &res, string(UninstallFilter), rCtx.FilterId); err != nil {
+	res, err := rCtx.EthCli.CallContract(ctx, msg, nil)
+	if err != nil {
 		return nil, err
 	}
-	if res {
-		return nil, errors.New("uninstall filter should be failed because it was already uninstalled")
-	}
 
-	result := &types.RpcResult{
-		Method: UninstallFilter,
-		Status: types.Ok,
-		Value:  rCtx.FilterId,
+	var warnings []string
+	if len(res) == 0 {
+		warnings = append(warnings, "returned data is empty, expected runtime bytecode")
+	} else if bytes.Equal(res, rCtx.ERC20ByteCode) {
+		warnings = append(warnings, "returned data equals deployment bytecode, expected runtime bytecode")
 	}
-	rCtx.AlreadyTestedRPCs = append(rCtx.AlreadyTestedRPCs, result)
 
-	return result, nil
+	return rCtx.RecordCustomResult(CallContractCreation, hexutils.BytesToHex(res), warnings), nil
 }
 
-func RpcGetLogs(rCtx *RpcContext) (*types.RpcResult, error) {
-	if result := rCtx.AlreadyTested(GetLogs); result != nil {
+func RpcCallWithAccessList(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(CallWithAccessList); result != nil {
 		return result, nil
 	}
 
-	if _, err := RpcNewFilter(rCtx); err != nil {
-		return nil, errors.New("failed to create a filter")
-	}
-
-	if _, err := RpcSendRawTransactionTransferERC20(rCtx); err != nil {
-		return nil, errors.New("transfer ERC20 must be succeeded before checking filter logs")
+	callResult, err := RPCCall(ctx, rCtx)
+	if err != nil {
+		return nil, errors.New("eth_call must be succeeded before checking eth_call with access list")
 	}
 
-	// set from block because of limit
-	logs, err := rCtx.EthCli.FilterLogs(context.Background(), rCtx.FilterQuery)
+	data, err := rCtx.ERC20Abi.Pack("balanceOf", rCtx.Acc.Address)
 	if err != nil {
-		return nil, err
+		log.Fatalf("Failed to pack transaction data: %v", err)
 	}
 
-	status := types.Ok
-	warnings := []string{}
-	if len(logs) == 0 {
-		status = types.Warning
-		warnings = append(warnings, "no logs")
+	// balanceOf mapping is at storage_at_slot_index of the ERC20 contract, like eth_getStorageAt
+	accessList := gethtypes.AccessList{
+		{
+			Address:     rCtx.ERC20Addr,
+			StorageKeys: []common.Hash{utils.MustCalculateSlotKey(rCtx.Acc.Address, rCtx.Conf.StorageAtSlotIndex)},
+		},
+	}
+	arg := map[string]interface{}{
+		"to":         rCtx.ERC20Addr,
+		"data":       hexutil.Bytes(data),
+		"accessList": accessList,
+	}
+	var res hexutil.Bytes
+	if err = rCtx.EthCli.Client().CallContext(ctx, &res, string(Call), arg, "latest"); err != nil {
+		return nil, err
 	}
 
-	result := &types.RpcResult{
-		Method:   GetLogs,
-		Status:   status,
-		Value:    utils.MustBeautifyLogs(logs),
-		Warnings: warnings,
+	value := hexutils.BytesToHex(res)
+	if value != callResult.Value {
+		return nil, fmt.Errorf("eth_call with access list returns different result: %s != %s", value, callResult.Value)
 	}
-	rCtx.AlreadyTestedRPCs = append(rCtx.AlreadyTestedRPCs, result)
 
-	return result, nil
+	return rCtx.RecordCustomResult(CallWithAccessList, value, nil), nil
 }
 
-func RpcEstimateGas(rCtx *RpcContext) (*types.RpcResult, error) {
-	if result := rCtx.AlreadyTested(EstimateGas); result != nil {
+const (
+	WaitStrategyPolling      = "polling"
+	WaitStrategySubscription = "subscription"
+)
+
+var errSubscriptionFailed = errors.New("newHeads subscription failed")
+
+// ErrInsufficientBalance is returned by RpcGetBalance when the rich account has less than the minimum balance,
+// the test run should stop because transactions would fail with confusing errors
+var ErrInsufficientBalance = errors.New("insufficient balance")
+
+func RpcCallWithLargeGas(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(CallWithLargeGas); result != nil {
 		return result, nil
 	}
 
@@ -1083,96 +3322,266 @@ func RpcEstimateGas(rCtx *RpcContext) (*types.RpcResult, error) {
 		return nil, errors.New("no contract address, must be deployed first")
 	}
 
-	data, err := rCtx.ERC20Abi.Pack("transfer", rCtx.Acc.Address, new(big.Int).SetUint64(1))
+	data, err := rCtx.ERC20Abi.Pack("balanceOf", rCtx.Acc.Address)
 	if err != nil {
 		log.Fatalf("Failed to pack transaction data: %v", err)
 	}
 
 	msg := ethereum.CallMsg{
-		From: rCtx.Acc.Address,
 		To:   &rCtx.ERC20Addr,
+		Gas:  math.MaxUint64,
 		Data: data,
 	}
-	gas, err := rCtx.EthCli.EstimateGas(context.Background(), msg)
-	if err != nil {
-		return nil, err
-	}
-
-	result := &types.RpcResult{
-		Method: EstimateGas,
-		Status: types.Ok,
-		Value:  gas,
+	res, err := rCtx.EthCli.CallContract(ctx, msg, nil)
+
+	switch {
+	case err == nil:
+		// the node caps the gas silently like geth does with rpc.gascap, the cap is not reported
+		value := fmt.Sprintf("succeeded with gas capped by node, result: %s", hexutils.BytesToHex(res))
+		return rCtx.RecordCustomResult(CallWithLargeGas, value, nil), nil
+	case isGasCapError(err):
+		// the node rejects the call with an error explaining the gas cap
+		value := fmt.Sprintf("rejected with gas cap error: %v", err)
+		if gasCap, ok := parseGasCap(err); ok {
+			value = fmt.Sprintf("rejected with gas cap error (effective gas cap: %d): %v", gasCap, err)
+		}
+		return rCtx.RecordCustomResult(CallWithLargeGas, value, nil), nil
+	default:
+		warnings := []string{"eth_call with max gas failed with an error which does not explain the gas cap"}
+		return rCtx.RecordCustomResult(CallWithLargeGas, err.Error(), warnings), nil
 	}
-	rCtx.AlreadyTestedRPCs = append(rCtx.AlreadyTestedRPCs, result)
+}
 
-	return result, nil
+// gasCapErrors are the messages of errors returned by nodes rejecting a call above their gas cap
+var gasCapErrors = []string{
+	"gas cap",
+	"gascap",
+	"exceeds block gas limit",
+	"exceeds the block gas limit",
+	"gas required exceeds allowance",
+	"gas limit too high",
+	"exceeds the configured cap",
 }
 
-func RPCCall(rCtx *RpcContext) (*types.RpcResult, error) {
-	if result := rCtx.AlreadyTested(Call); result != nil {
-		return result, nil
+func isGasCapError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, capErr := range gasCapErrors {
+		if strings.Contains(msg, capErr) {
+			return true
+		}
 	}
+	return false
+}
 
-	if rCtx.ERC20Addr == (common.Address{}) {
-		return nil, errors.New("no contract address, must be deployed first")
+var gasCapRegexp = regexp.MustCompile(`\d+`)
+
+// parseGasCap returns the gas cap in a gas cap error, which is the last number in the message
+// e.g. "gas required exceeds allowance (50000000)"
+func parseGasCap(err error) (uint64, bool) {
+	numbers := gasCapRegexp.FindAllString(err.Error(), -1)
+	if len(numbers) == 0 {
+		return 0, false
 	}
+	gasCap, parseErr := strconv.ParseUint(numbers[len(numbers)-1], 10, 64)
+	return gasCap, parseErr == nil
+}
 
-	data, err := rCtx.ERC20Abi.Pack("balanceOf", rCtx.Acc.Address)
+// WaitForTx waits for the transaction to be mined within timeout, which is bounded by the deadline of ctx
+func WaitForTx(ctx context.Context, rCtx *RpcContext, txHash common.Hash, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	defer func() { rCtx.TxWaitTime += time.Since(start) }()
+	strategy := WaitStrategyPolling
+	var receipt *gethtypes.Receipt
+	var err error
+	var polled chan time.Duration
+	if rCtx.Conf.UseSubscriptionForTx && rCtx.EthCliWs != nil {
+		// poll concurrently to compare the time-to-confirmation of both strategies
+		pollCtx, cancelPoll := context.WithCancel(ctx)
+		defer cancelPoll()
+		polled = make(chan time.Duration, 1)
+		go func() {
+			defer close(polled)
+			if _, err := waitForReceiptByPolling(pollCtx, rCtx, txHash); err == nil {
+				polled <- time.Since(start)
+			}
+		}()
+
+		strategy = WaitStrategySubscription
+		receipt, err = waitForReceiptBySubscription(ctx, rCtx, txHash)
+		if errors.Is(err, errSubscriptionFailed) {
+			// fall back to polling if subscription is not available
+			strategy = WaitStrategyPolling
+			polled = nil
+			receipt, err = waitForReceiptByPolling(ctx, rCtx, txHash)
+		}
+	} else {
+		receipt, err = waitForReceiptByPolling(ctx, rCtx, txHash)
+	}
 	if err != nil {
-		log.Fatalf("Failed to pack transaction data: %v", err)
+		return err
+	}
+	rCtx.TxConfirmationTimes[strategy] = append(rCtx.TxConfirmationTimes[strategy], time.Since(start))
+	if polled != nil {
+		if d, ok := <-polled; ok {
+			rCtx.TxConfirmationTimes[WaitStrategyPolling] = append(rCtx.TxConfirmationTimes[WaitStrategyPolling], d)
+		}
 	}
 
-	msg := ethereum.CallMsg{
-		To:   &rCtx.ERC20Addr,
-		Data: data,
+	rCtx.ProcessedTransactions = append(rCtx.ProcessedTransactions, txHash)
+	rCtx.BlockNumsIncludingTx = append(rCtx.BlockNumsIncludingTx, receipt.BlockNumber.Uint64())
+	if receipt.Status == 0 {
+		return fmt.Errorf("transaction %s failed", txHash.Hex())
 	}
-	res, err := rCtx.EthCli.CallContract(context.Background(), msg, nil)
-	if err != nil {
-		return nil, err
+	if receipt.ContractAddress != (common.Address{}) {
+		if err = verifyDeploymentReceipt(ctx, rCtx, receipt); err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	result := &types.RpcResult{
-		Method: Call,
-		Status: types.Ok,
-		Value:  hexutils.BytesToHex(res),
+// RecordTxConfirmationTimes records the average time-to-confirmation of transactions per WaitForTx strategy.
+// Subscription is warned if it is slower than polling on average, which defeats its purpose.
+func RecordTxConfirmationTimes(rCtx *RpcContext) *types.RpcResult {
+	if len(rCtx.TxConfirmationTimes) == 0 {
+		return nil
+	}
+	average := func(durations []time.Duration) time.Duration {
+		var total time.Duration
+		for _, d := range durations {
+			total += d
+		}
+		return total / time.Duration(len(durations))
 	}
-	rCtx.AlreadyTestedRPCs = append(rCtx.AlreadyTestedRPCs, result)
 
-	return result, nil
+	var values, warnings []string
+	for _, strategy := range []string{WaitStrategyPolling, WaitStrategySubscription} {
+		if durations := rCtx.TxConfirmationTimes[strategy]; len(durations) > 0 {
+			values = append(values, fmt.Sprintf("%s: avg %dms over %d txs", strategy, average(durations).Milliseconds(), len(durations)))
+		}
+	}
+	polling, subscription := rCtx.TxConfirmationTimes[WaitStrategyPolling], rCtx.TxConfirmationTimes[WaitStrategySubscription]
+	if len(polling) > 0 && len(subscription) > 0 && average(subscription) > average(polling) {
+		warnings = append(warnings, "subscription is slower than polling on average")
+	}
+	return rCtx.RecordCustomResult(TxConfirmationTimes, strings.Join(values, ", "), warnings)
 }
 
-func WaitForTx(rCtx *RpcContext, txHash common.Hash, timeout time.Duration) error {
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
+// verifyDeploymentReceipt checks that the deployed contract has code and
+// the receipt of the contract deployment has null to rather than the zero address
+func verifyDeploymentReceipt(ctx context.Context, rCtx *RpcContext, receipt *gethtypes.Receipt) error {
+	code, err := rCtx.EthCli.CodeAt(ctx, receipt.ContractAddress, nil)
+	if err != nil {
+		return err
+	}
+	if len(code) == 0 {
+		return fmt.Errorf("no code at contractAddress %s of deployment receipt", receipt.ContractAddress.Hex())
+	}
+
+	var raw map[string]interface{}
+	if err = rCtx.EthCli.Client().CallContext(ctx, &raw, string(GetTransactionReceipt), receipt.TxHash); err != nil {
+		return err
+	}
+	if to, exists := raw["to"]; exists && to != nil {
+		return fmt.Errorf("to of deployment receipt must be null, got %v", to)
+	}
+	return nil
+}
 
+// waitForReceiptByPolling polls eth_getTransactionReceipt until the receipt is found
+func waitForReceiptByPolling(ctx context.Context, rCtx *RpcContext, txHash common.Hash) (*gethtypes.Receipt, error) {
 	ticker := time.NewTicker(500 * time.Millisecond) // Check every 500ms
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
-			return fmt.Errorf("timeout exceeded while waiting for transaction %s", txHash.Hex())
+			return nil, fmt.Errorf("timeout exceeded while waiting for transaction %s", txHash.Hex())
 		case <-ticker.C:
-			receipt, err := rCtx.EthCli.TransactionReceipt(context.Background(), txHash)
+			receipt, err := rCtx.EthCli.TransactionReceipt(ctx, txHash)
 			if err != nil && !errors.Is(err, ethereum.NotFound) {
-				return err
+				return nil, err
 			}
 			if err == nil {
-				rCtx.ProcessedTransactions = append(rCtx.ProcessedTransactions, txHash)
-				rCtx.BlockNumsIncludingTx = append(rCtx.BlockNumsIncludingTx, receipt.BlockNumber.Uint64())
-				rCtx.AlreadyTestedRPCs = append(rCtx.AlreadyTestedRPCs, &types.RpcResult{
-					Method: GetTransactionReceipt,
-					Status: types.Ok,
-					Value:  utils.MustBeautifyReceipt(receipt),
-				})
-				if receipt.ContractAddress != (common.Address{}) {
-					rCtx.ERC20Addr = receipt.ContractAddress
-				}
-				if receipt.Status == 0 {
-					return fmt.Errorf("transaction %s failed", txHash.Hex())
-				}
-				return nil
+				return receipt, nil
 			}
 		}
 	}
 }
+
+// waitForReceiptBySubscription looks up the receipt whenever a new head is received from the newHeads subscription
+func waitForReceiptBySubscription(ctx context.Context, rCtx *RpcContext, txHash common.Hash) (*gethtypes.Receipt, error) {
+	headers := make(chan *gethtypes.Header)
+	sub, err := rCtx.EthCliWs.SubscribeNewHead(ctx, headers)
+	if err != nil {
+		return nil, errSubscriptionFailed
+	}
+	defer sub.Unsubscribe()
+
+	// the transaction may already be mined before subscribing
+	receipt, err := rCtx.EthCli.TransactionReceipt(ctx, txHash)
+	if err == nil {
+		return receipt, nil
+	}
+	if !errors.Is(err, ethereum.NotFound) {
+		return nil, err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("timeout exceeded while waiting for transaction %s", txHash.Hex())
+		case <-sub.Err():
+			return nil, errSubscriptionFailed
+		case <-headers:
+			receipt, err := rCtx.EthCli.TransactionReceipt(ctx, txHash)
+			if err != nil && !errors.Is(err, ethereum.NotFound) {
+				return nil, err
+			}
+			if err == nil {
+				return receipt, nil
+			}
+		}
+	}
+}
+
+func RpcCallWithBalanceOverride(ctx context.Context, rCtx *RpcContext) (*types.RpcResult, error) {
+	if result := rCtx.AlreadyTested(CallWithBalanceOverride); result != nil {
+		return result, nil
+	}
+
+	// the sender has no balance actually, and the threshold contract is injected by the code override
+	sender := rCtx.TempAccount().Address
+	contractAddr := crypto.CreateAddress(sender, 0)
+	arg := map[string]interface{}{
+		"from": sender,
+		"to":   contractAddr,
+	}
+	overrides := map[common.Address]interface{}{
+		sender: map[string]interface{}{
+			"balance": (*hexutil.Big)(new(big.Int).Mul(big.NewInt(params.Ether), big.NewInt(1000))),
+		},
+		contractAddr: map[string]interface{}{
+			"code": hexutil.Bytes(common.FromHex(string(contracts.BalanceThresholdRuntimeByteCode))),
+		},
+	}
+	var res hexutil.Bytes
+	if err := rCtx.EthCli.Client().CallContext(ctx, &res, string(Call), arg, "latest", overrides); err != nil {
+		warnings := []string{fmt.Sprintf("state overrides may not be supported: %v", err)}
+		return rCtx.RecordCustomResult(CallWithBalanceOverride, nil, warnings), nil
+	}
+	// a node ignoring the overrides calls an address without code, which returns empty output
+	if len(res) == 0 {
+		warnings := []string{"eth_call returns empty output, state overrides may not be supported"}
+		return rCtx.RecordCustomResult(CallWithBalanceOverride, "0x", warnings), nil
+	}
+
+	if new(big.Int).SetBytes(res).Cmp(big.NewInt(1)) != 0 {
+		return nil, fmt.Errorf("eth_call with balance override returns %s, expected 1", hexutils.BytesToHex(res))
+	}
+
+	return rCtx.RecordCustomResult(CallWithBalanceOverride, hexutils.BytesToHex(res), nil), nil
+}
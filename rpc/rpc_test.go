@@ -0,0 +1,444 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/b-harvest/ethrpc-checker/config"
+	"github.com/b-harvest/ethrpc-checker/types"
+)
+
+// newMockServer starts a JSON-RPC server answering each method with the result in results
+// after waiting delay
+func newMockServer(t *testing.T, delay time.Duration, results map[string]interface{}) *httptest.Server {
+	t.Helper()
+	return newMockServerFunc(t, delay, func(method string, _ []json.RawMessage) (interface{}, bool) {
+		result, ok := results[method]
+		return result, ok
+	})
+}
+
+// newMockServerFunc starts a JSON-RPC server answering each request with the result of handle,
+// or method not found if handle returns false
+func newMockServerFunc(t *testing.T, delay time.Duration, handle func(method string, params []json.RawMessage) (interface{}, bool)) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+		var req struct {
+			ID     json.RawMessage   `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		resp := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+		if result, ok := handle(req.Method, req.Params); ok {
+			resp["result"] = result
+		} else {
+			resp["error"] = map[string]interface{}{"code": -32601, "message": "method not found"}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func testConfig(endpoint string) *config.Config {
+	return &config.Config{
+		RpcEndpoint:      endpoint,
+		RichPrivKey:      "b9d15599650f41dc705d1edf676830117d14bf41f7a06dac5d13228507cff77f",
+		Timeout:          "10s",
+		ConnectTimeoutMs: 100,
+		MinBalance:       "0.01",
+	}
+}
+
+func TestNewContextConnectTimeout(t *testing.T) {
+	srv := newMockServer(t, time.Second, map[string]interface{}{"eth_chainId": "0x1"})
+
+	start := time.Now()
+	_, err := NewContext(testConfig(srv.URL))
+	if err == nil {
+		t.Fatal("expected connect timeout error")
+	}
+	if !strings.Contains(err.Error(), "failed to connect to RPC endpoint within 100ms") {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 900*time.Millisecond {
+		t.Errorf("NewContext returned after %v, the connect timeout is not applied", elapsed)
+	}
+}
+
+func TestNewContext(t *testing.T) {
+	srv := newMockServer(t, 0, map[string]interface{}{
+		"eth_chainId":    "0x1",
+		"eth_getBalance": "0xde0b6b3a7640000", // 1 ETH
+	})
+
+	rCtx, err := NewContext(testConfig(srv.URL))
+	if err != nil {
+		t.Fatalf("NewContext failed: %v", err)
+	}
+	if rCtx.EthCliWs != nil {
+		t.Error("websocket client must not be created without ws_endpoint")
+	}
+	if rCtx.ChainId == nil || rCtx.ChainId.Int64() != 1 {
+		t.Errorf("chain id %v, want 1", rCtx.ChainId)
+	}
+}
+
+func TestNewContextBalanceTimeout(t *testing.T) {
+	// the node accepts the connection but hangs on eth_getBalance
+	srv := newMockServerFunc(t, 0, func(method string, _ []json.RawMessage) (interface{}, bool) {
+		switch method {
+		case "eth_chainId":
+			return "0x1", true
+		case "eth_getBalance":
+			time.Sleep(time.Second)
+			return "0xde0b6b3a7640000", true
+		}
+		return nil, false
+	})
+
+	start := time.Now()
+	_, err := NewContext(testConfig(srv.URL))
+	if err == nil || !strings.Contains(err.Error(), "within 100ms") {
+		t.Fatalf("expected balance timeout error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 900*time.Millisecond {
+		t.Errorf("NewContext returned after %v, the connect timeout is not applied to the balance check", elapsed)
+	}
+}
+
+func TestNewContextExpectedChainId(t *testing.T) {
+	srv := newMockServer(t, 0, map[string]interface{}{
+		"eth_chainId":    "0x1",
+		"eth_getBalance": "0xde0b6b3a7640000",
+	})
+
+	conf := testConfig(srv.URL)
+	conf.ExpectedChainId = 9000
+	_, err := NewContext(conf)
+	if err == nil || !strings.Contains(err.Error(), "does not match expected_chain_id 9000") {
+		t.Fatalf("expected chain id mismatch error, got %v", err)
+	}
+
+	conf.ExpectedChainId = 1
+	if _, err = NewContext(conf); err != nil {
+		t.Fatalf("NewContext failed with matching chain id: %v", err)
+	}
+}
+
+func TestNewContextWsEndpointWithoutSubscription(t *testing.T) {
+	srv := newMockServer(t, 0, map[string]interface{}{
+		"eth_chainId":    "0x1",
+		"eth_getBalance": "0xde0b6b3a7640000",
+	})
+
+	// the websocket endpoint is unreachable, but it is not dialed without use_subscription_for_tx
+	conf := testConfig(srv.URL)
+	conf.WsEndpoint = "ws://127.0.0.1:1"
+	rCtx, err := NewContext(conf)
+	if err != nil {
+		t.Fatalf("NewContext failed: %v", err)
+	}
+	if rCtx.EthCliWs != nil {
+		t.Error("websocket client must not be created without use_subscription_for_tx")
+	}
+
+	conf.UseSubscriptionForTx = true
+	if _, err = NewContext(conf); err == nil {
+		t.Error("expected an error dialing the unreachable websocket endpoint")
+	}
+}
+
+func TestRecordTxConfirmationTimes(t *testing.T) {
+	rCtx := &RpcContext{
+		TestedRPCs:          make(map[types.RpcName]*types.RpcResult),
+		TxConfirmationTimes: make(map[string][]time.Duration),
+	}
+	if res := RecordTxConfirmationTimes(rCtx); res != nil {
+		t.Fatalf("expected no result without transactions, got %+v", res)
+	}
+
+	rCtx.TxConfirmationTimes[WaitStrategyPolling] = []time.Duration{time.Second, 3 * time.Second}
+	rCtx.TxConfirmationTimes[WaitStrategySubscription] = []time.Duration{500 * time.Millisecond, 1500 * time.Millisecond}
+	res := RecordTxConfirmationTimes(rCtx)
+	if res.Status != types.Ok {
+		t.Errorf("status %s, want ok: %v", res.Status, res.Warnings)
+	}
+	if want := "polling: avg 2000ms over 2 txs, subscription: avg 1000ms over 2 txs"; res.Value != want {
+		t.Errorf("value %q, want %q", res.Value, want)
+	}
+
+	// subscription slower than polling is warned
+	rCtx.TestedRPCs = make(map[types.RpcName]*types.RpcResult)
+	rCtx.TxConfirmationTimes[WaitStrategySubscription] = []time.Duration{5 * time.Second}
+	if res = RecordTxConfirmationTimes(rCtx); res.Status != types.Warning {
+		t.Errorf("status %s, want warning", res.Status)
+	}
+}
+
+func TestGetBlockByTagResults(t *testing.T) {
+	srv := newMockServer(t, 0, map[string]interface{}{
+		"eth_chainId":    "0x1",
+		"eth_getBalance": "0xde0b6b3a7640000",
+		"eth_getBlockByNumber": map[string]interface{}{
+			"number": "0x0",
+			"hash":   "0xd4e56740f876aef8c010b86a40d5f56745a118d0906a34e69aec8c0db1cb8fa3",
+		},
+	})
+	rCtx, err := NewContext(testConfig(srv.URL))
+	if err != nil {
+		t.Fatalf("NewContext failed: %v", err)
+	}
+
+	// each tag has its own row, so every function must return the result of its own method
+	tests := []struct {
+		name types.RpcName
+		test func() (*types.RpcResult, error)
+	}{
+		{GetEarliestBlock, func() (*types.RpcResult, error) { return RpcGetEarliestBlock(context.Background(), rCtx) }},
+		{GetSafeBlock, func() (*types.RpcResult, error) { return RpcGetSafeBlock(context.Background(), rCtx) }},
+		{GetFinalizedBlock, func() (*types.RpcResult, error) { return RpcGetFinalizedBlock(context.Background(), rCtx) }},
+	}
+	for _, tt := range tests {
+		result, err := tt.test()
+		if err != nil {
+			t.Fatalf("%s failed: %v", tt.name, err)
+		}
+		if result.Method != tt.name {
+			t.Errorf("%s returned the result of %s", tt.name, result.Method)
+		}
+		if result.Status != types.Ok {
+			t.Errorf("%s: status %s, want ok", tt.name, result.Status)
+		}
+	}
+}
+
+func TestGetBlockByTagNull(t *testing.T) {
+	srv := newMockServer(t, 0, map[string]interface{}{
+		"eth_chainId":          "0x1",
+		"eth_getBalance":       "0xde0b6b3a7640000",
+		"eth_getBlockByNumber": nil,
+	})
+	rCtx, err := NewContext(testConfig(srv.URL))
+	if err != nil {
+		t.Fatalf("NewContext failed: %v", err)
+	}
+
+	// a null safe block is a warning, but the earliest block must exist
+	result, err := RpcGetSafeBlock(context.Background(), rCtx)
+	if err != nil {
+		t.Fatalf("RpcGetSafeBlock failed: %v", err)
+	}
+	if result.Status != types.Warning {
+		t.Errorf("status %s, want warning", result.Status)
+	}
+	if _, err = RpcGetEarliestBlock(context.Background(), rCtx); err == nil {
+		t.Error("expected an error for a null earliest block")
+	}
+}
+
+func TestGasCapError(t *testing.T) {
+	tests := []struct {
+		msg    string
+		isCap  bool
+		gasCap uint64
+		hasCap bool
+	}{
+		{"gas required exceeds allowance (50000000)", true, 50000000, true},
+		{"err: exceeds block gas limit", true, 0, false},
+		{"gas limit too high: 18446744073709551615 > 30000000", true, 30000000, true},
+		{"insufficient funds for gas * price + value", false, 0, false},
+		{"intrinsic gas too low", false, 0, false},
+		{"execution reverted", false, 0, false},
+	}
+	for _, tt := range tests {
+		err := errors.New(tt.msg)
+		if got := isGasCapError(err); got != tt.isCap {
+			t.Errorf("isGasCapError(%q) = %v, want %v", tt.msg, got, tt.isCap)
+		}
+		if !tt.isCap {
+			continue
+		}
+		gasCap, ok := parseGasCap(err)
+		if ok != tt.hasCap || gasCap != tt.gasCap {
+			t.Errorf("parseGasCap(%q) = %d, %v, want %d, %v", tt.msg, gasCap, ok, tt.gasCap, tt.hasCap)
+		}
+	}
+}
+
+func TestCallWithBalanceOverride(t *testing.T) {
+	tests := []struct {
+		name       string
+		output     string
+		wantStatus types.RpcStatus
+		wantErr    bool
+	}{
+		{name: "overrides applied", output: "0x0000000000000000000000000000000000000000000000000000000000000001", wantStatus: types.Ok},
+		{name: "overrides ignored", output: "0x", wantStatus: types.Warning},
+		{name: "balance not overridden", output: "0x0000000000000000000000000000000000000000000000000000000000000000", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := newMockServer(t, 0, map[string]interface{}{
+				"eth_chainId":    "0x1",
+				"eth_getBalance": "0xde0b6b3a7640000",
+				"eth_call":       tc.output,
+			})
+			rCtx, err := NewContext(testConfig(srv.URL))
+			if err != nil {
+				t.Fatalf("NewContext failed: %v", err)
+			}
+
+			result, err := RpcCallWithBalanceOverride(context.Background(), rCtx)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Status != tc.wantStatus {
+				t.Errorf("status %s, want %s", result.Status, tc.wantStatus)
+			}
+		})
+	}
+}
+
+func TestGetTransactionCountRecorded(t *testing.T) {
+	srv := newMockServer(t, 0, map[string]interface{}{
+		"eth_chainId":             "0x1",
+		"eth_getBalance":          "0xde0b6b3a7640000",
+		"eth_getTransactionCount": "0x5",
+	})
+	rCtx, err := NewContext(testConfig(srv.URL))
+	if err != nil {
+		t.Fatalf("NewContext failed: %v", err)
+	}
+
+	result, err := RpcGetTransactionCount(context.Background(), rCtx)
+	if err != nil {
+		t.Fatalf("RpcGetTransactionCount failed: %v", err)
+	}
+	// the result must be recorded to appear in the reports
+	if recorded := rCtx.AlreadyTested(GetTransactionCount); recorded != result {
+		t.Fatalf("recorded result %+v, want %+v", recorded, result)
+	}
+	if result.Status != types.Ok || result.Value != uint64(5) {
+		t.Errorf("unexpected result %+v", result)
+	}
+}
+
+func TestWaitForTxDoesNotSetERC20Addr(t *testing.T) {
+	for _, status := range []string{"0x1", "0x0"} {
+		srv := newMockServer(t, 0, map[string]interface{}{
+			"eth_chainId":    "0x1",
+			"eth_getBalance": "0xde0b6b3a7640000",
+			"eth_getCode":    "0x6000",
+			"eth_getTransactionReceipt": map[string]interface{}{
+				"status":            status,
+				"cumulativeGasUsed": "0x5208",
+				"gasUsed":           "0x5208",
+				"logsBloom":         "0x" + strings.Repeat("00", 256),
+				"logs":              []interface{}{},
+				"transactionHash":   "0x" + strings.Repeat("11", 32),
+				"contractAddress":   "0x" + strings.Repeat("22", 20),
+				"blockHash":         "0x" + strings.Repeat("33", 32),
+				"blockNumber":       "0x10",
+				"transactionIndex":  "0x0",
+			},
+		})
+		rCtx, err := NewContext(testConfig(srv.URL))
+		if err != nil {
+			t.Fatalf("NewContext failed: %v", err)
+		}
+
+		// a helper contract deployed before the ERC20 contract must not be taken as the ERC20 contract
+		err = WaitForTx(context.Background(), rCtx, common.HexToHash("0x"+strings.Repeat("11", 32)), 5*time.Second)
+		if status == "0x1" && err != nil {
+			t.Fatalf("WaitForTx failed: %v", err)
+		}
+		if status == "0x0" && err == nil {
+			t.Fatal("expected an error for a reverted deployment")
+		}
+		if rCtx.ERC20Addr != (common.Address{}) || rCtx.ERC20DeployReceipt != nil {
+			t.Errorf("status %s: ERC20Addr is set to %s by WaitForTx", status, rCtx.ERC20Addr.Hex())
+		}
+	}
+}
+
+func TestGetFinalizedState(t *testing.T) {
+	tests := []struct {
+		name             string
+		finalizedBalance string
+		finalizedNonce   string
+		wantErr          string
+	}{
+		{name: "consistent", finalizedBalance: "0xde0b6b3a7640000", finalizedNonce: "0x5"},
+		{name: "finalized lags behind", finalizedBalance: "0xde0b6b3a7640000", finalizedNonce: "0x3"},
+		// no transaction is sent in the test, so the balance at finalized can't exceed latest
+		{name: "balance greater at finalized", finalizedBalance: "0x1bc16d674ec80000", finalizedNonce: "0x5", wantErr: "greater than balance at latest"},
+		{name: "nonce greater at finalized", finalizedBalance: "0xde0b6b3a7640000", finalizedNonce: "0x6", wantErr: "greater than nonce at latest"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := newMockServerFunc(t, 0, func(method string, params []json.RawMessage) (interface{}, bool) {
+				finalized := len(params) > 1 && string(params[1]) == `"finalized"`
+				switch method {
+				case "eth_chainId":
+					return "0x1", true
+				case "eth_getBalance":
+					if finalized {
+						return tc.finalizedBalance, true
+					}
+					return "0xde0b6b3a7640000", true
+				case "eth_getTransactionCount":
+					if finalized {
+						return tc.finalizedNonce, true
+					}
+					return "0x5", true
+				}
+				return nil, false
+			})
+			rCtx, err := NewContext(testConfig(srv.URL))
+			if err != nil {
+				t.Fatalf("NewContext failed: %v", err)
+			}
+
+			result, err := RpcGetFinalizedState(context.Background(), rCtx)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("error %v, want %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Status != types.Ok {
+				t.Errorf("status %s, want ok", result.Status)
+			}
+		})
+	}
+}
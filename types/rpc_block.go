@@ -4,6 +4,7 @@ import (
 	"reflect"
 	"sync/atomic"
 	"time"
+	"unicode/utf8"
 	"unsafe"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -17,6 +18,8 @@ type RpcBlock struct {
 	Uncles       []*types.Header
 	Transactions []*types.Transaction
 	Withdrawals  []*types.Withdrawal
+	// ExtraDataText is extraData of the header decoded as UTF-8, miners often include identifying text there
+	ExtraDataText string `json:"extra_data_text,omitempty"`
 
 	// Cache fields
 	Hash atomic.Pointer[common.Hash] `json:"hash"`
@@ -38,14 +41,20 @@ func NewRpcBlock(block *types.Block) *RpcBlock {
 
 	sizeField := blockValue.FieldByName("size")
 	size := *(*atomic.Uint64)(unsafe.Pointer(sizeField.UnsafeAddr()))
+
+	var extraDataText string
+	if utf8.Valid(block.Extra()) {
+		extraDataText = string(block.Extra())
+	}
 	return &RpcBlock{
-		Header:       block.Header(),
-		Uncles:       block.Uncles(),
-		Transactions: block.Transactions(),
-		Withdrawals:  block.Withdrawals(),
-		Hash:         hash,
-		Size:         size,
-		ReceivedAt:   blockValue.FieldByName("ReceivedAt").Interface().(time.Time),
-		ReceivedFrom: blockValue.FieldByName("ReceivedFrom").Interface(),
+		Header:        block.Header(),
+		Uncles:        block.Uncles(),
+		Transactions:  block.Transactions(),
+		Withdrawals:   block.Withdrawals(),
+		ExtraDataText: extraDataText,
+		Hash:          hash,
+		Size:          size,
+		ReceivedAt:    blockValue.FieldByName("ReceivedAt").Interface().(time.Time),
+		ReceivedFrom:  blockValue.FieldByName("ReceivedFrom").Interface(),
 	}
 }
@@ -1,11 +1,15 @@
 package types
 
+import "time"
+
 type RpcStatus string
 
 const (
 	Ok      RpcStatus = "ok"
 	Error   RpcStatus = "error"
 	Warning RpcStatus = "warning"
+	// Skipped is the status of a method excluded from the test run
+	Skipped RpcStatus = "skipped"
 )
 
 type RpcName string
@@ -16,10 +20,18 @@ type RpcResult struct {
 	Value    interface{}
 	Warnings []string
 	ErrMsg   string
+	// Attempts is the number of times the test was run, recorded by rpc.WithRetry and shown in the
+	// verbose output when the test was retried. It is the attempt count of the retry, there is no separate
+	// AttemptCount field.
+	Attempts int
+	// Latency is the wall time of the test excluding waiting for transactions to be mined
+	Latency time.Duration
 }
 
 func GetStatusPriority(status RpcStatus) int {
 	switch status {
+	case Skipped:
+		return 0
 	case Ok:
 		return 1
 	case Warning:
@@ -30,3 +42,63 @@ func GetStatusPriority(status RpcStatus) int {
 		return 4
 	}
 }
+
+// Equal compares two results by method, status and error message.
+// Value is ignored because it changes between runs.
+func (r *RpcResult) Equal(other *RpcResult) bool {
+	if r == nil || other == nil {
+		return r == other
+	}
+	return r.Method == other.Method && r.Status == other.Status && r.ErrMsg == other.ErrMsg
+}
+
+// ResultDiff describes a method whose status or error message changed between two runs
+type ResultDiff struct {
+	Method    RpcName
+	OldStatus RpcStatus
+	NewStatus RpcStatus
+	OldErr    string
+	NewErr    string
+}
+
+// DiffResults returns the methods whose status or error message changed from baseline to current.
+// Results of the same method are matched in the order they appear, and a missing result has an empty status.
+func DiffResults(baseline, current []*RpcResult) []ResultDiff {
+	type key struct {
+		method RpcName
+		index  int
+	}
+	index := func(results []*RpcResult) (map[key]*RpcResult, []key) {
+		m := make(map[key]*RpcResult)
+		var keys []key
+		counts := make(map[RpcName]int)
+		for _, r := range results {
+			k := key{r.Method, counts[r.Method]}
+			counts[r.Method]++
+			m[k] = r
+			keys = append(keys, k)
+		}
+		return m, keys
+	}
+	oldResults, oldKeys := index(baseline)
+	newResults, newKeys := index(current)
+
+	var diffs []ResultDiff
+	for _, k := range oldKeys {
+		o, n := oldResults[k], newResults[k]
+		if n == nil {
+			diffs = append(diffs, ResultDiff{Method: k.method, OldStatus: o.Status, OldErr: o.ErrMsg})
+			continue
+		}
+		if !o.Equal(n) {
+			diffs = append(diffs, ResultDiff{Method: k.method, OldStatus: o.Status, NewStatus: n.Status, OldErr: o.ErrMsg, NewErr: n.ErrMsg})
+		}
+	}
+	for _, k := range newKeys {
+		if _, ok := oldResults[k]; !ok {
+			n := newResults[k]
+			diffs = append(diffs, ResultDiff{Method: k.method, NewStatus: n.Status, NewErr: n.ErrMsg})
+		}
+	}
+	return diffs
+}
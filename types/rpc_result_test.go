@@ -0,0 +1,79 @@
+package types
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRpcResultEqual(t *testing.T) {
+	base := &RpcResult{Method: "eth_chainId", Status: Ok, Value: "9000"}
+	tests := []struct {
+		name  string
+		a, b  *RpcResult
+		equal bool
+	}{
+		{"same", base, &RpcResult{Method: "eth_chainId", Status: Ok, Value: "9000"}, true},
+		{"value ignored", base, &RpcResult{Method: "eth_chainId", Status: Ok, Value: "1"}, true},
+		{"status", base, &RpcResult{Method: "eth_chainId", Status: Warning}, false},
+		{"errMsg", base, &RpcResult{Method: "eth_chainId", Status: Ok, ErrMsg: "timeout"}, false},
+		{"method", base, &RpcResult{Method: "net_version", Status: Ok}, false},
+		{"nil", base, nil, false},
+		{"both nil", nil, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.Equal(tt.b); got != tt.equal {
+				t.Errorf("Equal() = %v, want %v", got, tt.equal)
+			}
+		})
+	}
+}
+
+func TestDiffResults(t *testing.T) {
+	baseline := []*RpcResult{
+		{Method: "eth_chainId", Status: Ok, Value: "9000"},
+		{Method: "eth_getProof", Status: Error, ErrMsg: "method not found"},
+		{Method: "eth_feeHistory", Status: Warning},
+		{Method: "eth_getLogs", Status: Ok},
+	}
+	current := []*RpcResult{
+		{Method: "eth_chainId", Status: Ok, Value: "9001"},
+		{Method: "eth_getProof", Status: Ok},
+		{Method: "eth_feeHistory", Status: Warning},
+		{Method: "eth_blobBaseFee", Status: Error, ErrMsg: "method not found"},
+	}
+
+	want := []ResultDiff{
+		{Method: "eth_getProof", OldStatus: Error, NewStatus: Ok, OldErr: "method not found"},
+		{Method: "eth_getLogs", OldStatus: Ok},
+		{Method: "eth_blobBaseFee", NewStatus: Error, NewErr: "method not found"},
+	}
+	if got := DiffResults(baseline, current); !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffResults() = %+v, want %+v", got, want)
+	}
+}
+
+func TestDiffResultsDuplicateMethods(t *testing.T) {
+	baseline := []*RpcResult{
+		{Method: "eth_call", Status: Ok},
+		{Method: "eth_call", Status: Ok},
+	}
+	current := []*RpcResult{
+		{Method: "eth_call", Status: Ok},
+		{Method: "eth_call", Status: Error, ErrMsg: "execution reverted"},
+	}
+
+	want := []ResultDiff{
+		{Method: "eth_call", OldStatus: Ok, NewStatus: Error, NewErr: "execution reverted"},
+	}
+	if got := DiffResults(baseline, current); !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffResults() = %+v, want %+v", got, want)
+	}
+}
+
+func TestDiffResultsNoChanges(t *testing.T) {
+	results := []*RpcResult{{Method: "eth_chainId", Status: Ok}}
+	if got := DiffResults(results, results); len(got) != 0 {
+		t.Errorf("expected no diffs, got %+v", got)
+	}
+}
@@ -1,11 +1,15 @@
 package utils
 
 import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
 	"math/big"
+	"strings"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
@@ -13,7 +17,9 @@ import (
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	gethtypes "github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/google/go-cmp/cmp"
 
 	"github.com/b-harvest/ethrpc-checker/types"
 )
@@ -22,6 +28,7 @@ const (
 	RED    = "#FF0000"
 	YELLOW = "#FFFF00"
 	GREEN  = "#00FF00"
+	GREY   = "#808080"
 )
 
 // MustCreateRandomAccount creates a new Ethereum account with a random private key
@@ -36,6 +43,19 @@ func MustCreateRandomAccount() types.Account {
 	return acc
 }
 
+// AccountFromSeed derives a deterministic Ethereum account from a seed using HMAC-SHA256
+func AccountFromSeed(seed []byte) types.Account {
+	mac := hmac.New(sha256.New, seed)
+	mac.Write([]byte("ethrpc-checker"))
+	acc := types.Account{}
+	var err error
+	if acc.PrivKey, err = crypto.ToECDSA(mac.Sum(nil)); err != nil {
+		log.Fatal(err)
+	}
+	acc.Address = crypto.PubkeyToAddress(acc.PrivKey.PublicKey)
+	return acc
+}
+
 // MustBeautifyBlock formats and prints an Ethereum block in a readable JSON format
 func MustBeautifyBlock(block *types.RpcBlock) string {
 	blockJSON, err := json.MarshalIndent(block, "", "  ")
@@ -86,6 +106,42 @@ func MustBeautifyTransaction(tx *gethtypes.Transaction) string {
 	return string(indentedTxJSON)
 }
 
+// DiffBlocks compares two Ethereum blocks and returns a human-readable diff of header fields,
+// transaction count and withdrawals count. It returns an empty string if no difference is found.
+func DiffBlocks(a, b *gethtypes.Block) string {
+	var diffs []string
+	bigIntComparer := cmp.Comparer(func(x, y *big.Int) bool {
+		if x == nil || y == nil {
+			return x == y
+		}
+		return x.Cmp(y) == 0
+	})
+	if diff := cmp.Diff(a.Header(), b.Header(), bigIntComparer); diff != "" {
+		diffs = append(diffs, fmt.Sprintf("header: %s", diff))
+	}
+	if len(a.Transactions()) != len(b.Transactions()) {
+		diffs = append(diffs, fmt.Sprintf("transactions count: %d != %d", len(a.Transactions()), len(b.Transactions())))
+	} else {
+		for i, tx := range a.Transactions() {
+			if tx.Hash() != b.Transactions()[i].Hash() {
+				diffs = append(diffs, fmt.Sprintf("transaction %d hash: %s != %s", i, tx.Hash().Hex(), b.Transactions()[i].Hash().Hex()))
+			}
+		}
+	}
+	if len(a.Withdrawals()) != len(b.Withdrawals()) {
+		diffs = append(diffs, fmt.Sprintf("withdrawals count: %d != %d", len(a.Withdrawals()), len(b.Withdrawals())))
+	}
+	return strings.Join(diffs, "\n")
+}
+
+// TruncateString truncates a string to the given max length
+func TruncateString(s string, maxLen int) string {
+	if len(s) <= maxLen {
+		return s
+	}
+	return s[:maxLen] + "..."
+}
+
 func MustCalculateSlotKey(addr common.Address, slotIndex uint64) common.Hash {
 	addressTy, err := abi.NewType("address", "", nil)
 	if err != nil {
@@ -104,6 +160,25 @@ func MustCalculateSlotKey(addr common.Address, slotIndex uint64) common.Hash {
 	return crypto.Keccak256Hash(packedArgs)
 }
 
+// ParseEther parses a decimal ETH string (e.g. "0.01") into wei
+func ParseEther(s string) (*big.Int, error) {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return nil, fmt.Errorf("invalid ETH amount: %s", s)
+	}
+	r.Mul(r, new(big.Rat).SetInt(big.NewInt(params.Ether)))
+	if !r.IsInt() {
+		return nil, fmt.Errorf("ETH amount has more than 18 decimals: %s", s)
+	}
+	return r.Num(), nil
+}
+
+// FormatEther formats wei as a decimal ETH string
+func FormatEther(wei *big.Int) string {
+	r := new(big.Rat).SetFrac(wei, big.NewInt(params.Ether))
+	return strings.TrimRight(strings.TrimRight(r.FloatString(18), "0"), ".")
+}
+
 // IsZeroBytes checks if a byte slice consists only of zero bytes
 func IsZeroBytes(b []byte) bool {
 	for _, v := range b {
@@ -114,6 +189,23 @@ func IsZeroBytes(b []byte) bool {
 	return true
 }
 
+// IsRuntimeBytecode reports whether code looks like Solidity runtime bytecode. It must start with
+// the free memory pointer setup only once (deployment bytecode embeds the runtime code, which has
+// it again) and end with CBOR encoded metadata, which is broken by appended constructor arguments.
+func IsRuntimeBytecode(code []byte) bool {
+	freeMemPtr := []byte{0x60, 0x80, 0x60, 0x40, 0x52} // PUSH1 0x80 PUSH1 0x40 MSTORE
+	if !bytes.HasPrefix(code, freeMemPtr) || bytes.Count(code, freeMemPtr) != 1 {
+		return false
+	}
+	// the last 2 bytes are the length of the metadata, which starts with a CBOR map
+	metadataLen := int(code[len(code)-2])<<8 | int(code[len(code)-1])
+	start := len(code) - 2 - metadataLen
+	if start < 0 {
+		return false
+	}
+	return code[start] >= 0xa1 && code[start] <= 0xa5
+}
+
 func MustBeautifyLogs(logs []gethtypes.Log) string {
 	receiptsJSON, err := json.MarshalIndent(logs, "", "  ")
 	if err != nil {
@@ -157,3 +249,10 @@ func toBlockNumArg(number *big.Int) string {
 	// It's negative and large, which is invalid.
 	return fmt.Sprintf("<invalid %d>", number)
 }
+
+// MustCalculateNestedSlotKey calculates the storage key of mapping[outerKey][innerKey]
+// for a nested mapping declared at slotIndex
+func MustCalculateNestedSlotKey(outerKey, innerKey common.Address, slotIndex uint64) common.Hash {
+	outerSlot := MustCalculateSlotKey(outerKey, slotIndex)
+	return crypto.Keccak256Hash(common.LeftPadBytes(innerKey.Bytes(), common.HashLength), outerSlot.Bytes())
+}
@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestMustCalculateSlotKey(t *testing.T) {
+	// keccak256 of 64 zero bytes, the slot of key 0 of a mapping at slot 0
+	want := common.HexToHash("0xad3228b676f7d3cd4284a5443f17f1962b36e491b30a40b2405849e597ba5fb5")
+	if got := MustCalculateSlotKey(common.Address{}, 0); got != want {
+		t.Errorf("MustCalculateSlotKey() = %s, want %s", got, want)
+	}
+}
+
+func TestMustCalculateNestedSlotKey(t *testing.T) {
+	owner := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	spender := common.HexToAddress("0x2222222222222222222222222222222222222222")
+
+	// allowance[owner][spender] of the ERC20 contract, whose allowance mapping is at slot 5:
+	// keccak256(spender . keccak256(owner . 5))
+	want := common.HexToHash("0xf825e63208a6fe6bdf5e6738b6a0d95f8b34351bc772559bbdc14f4a1baf692e")
+	if got := MustCalculateNestedSlotKey(owner, spender, 5); got != want {
+		t.Errorf("MustCalculateNestedSlotKey() = %s, want %s", got, want)
+	}
+
+	// the outer slot is the slot of owner in a mapping at slot 5
+	outer := common.HexToHash("0xe211e23e74ee2556989cb624831cd15e4324ec0f5e5d9a1c3ec21f309f497c8c")
+	if got := MustCalculateSlotKey(owner, 5); got != outer {
+		t.Errorf("MustCalculateSlotKey() = %s, want %s", got, outer)
+	}
+
+	// swapping the keys must give a different slot
+	if MustCalculateNestedSlotKey(spender, owner, 5) == want {
+		t.Error("MustCalculateNestedSlotKey() is symmetric in its keys")
+	}
+}